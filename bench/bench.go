@@ -0,0 +1,151 @@
+// Package bench measures the costs of white-box constructions, so that the trade-offs between them can be compared
+// automatically.
+//
+// For each construction and key generation option, it records how long key generation takes, how large the serialized
+// construction is, how much heap the parsed construction holds, and how fast the parsed construction evaluates. Results
+// are plain structs that encode to JSON.
+package bench
+
+import (
+	"encoding/json"
+	"io"
+	"runtime"
+	"time"
+
+	"github.com/OpenWhiteBox/AES/constructions/chow"
+	"github.com/OpenWhiteBox/AES/constructions/common"
+	"github.com/OpenWhiteBox/AES/constructions/full"
+	"github.com/OpenWhiteBox/AES/constructions/toy"
+	"github.com/OpenWhiteBox/AES/constructions/xiao"
+)
+
+// Target is one construction and option combination to measure.
+type Target struct {
+	Type     common.ConstructionType
+	Opts     common.KeyGenerationOpts // nil for constructions without options, like toy and full.
+	Generate func(key, seed []byte) common.Construction
+}
+
+// Result is the measurements of one Target.
+type Result struct {
+	Construction string `json:"construction"`
+	Options      string `json:"options,omitempty"`
+
+	KeygenNanos    int64   `json:"keygenNanos"`    // Wall-clock time of key generation.
+	SerializedSize int     `json:"serializedSize"` // Size of the serialized construction, in bytes.
+	HeapBytes      uint64  `json:"heapBytes"`      // Heap held by the parsed construction.
+	BlocksPerSec   float64 `json:"blocksPerSec"`   // Encryption throughput of the parsed construction.
+}
+
+// Targets returns every construction in the repository, with each of the masks it accepts. Device binding and
+// watermarks wrap these options without changing the tables' sizes or structure, so they aren't listed separately.
+func Targets() []Target {
+	opts := []common.KeyGenerationOpts{
+		common.IndependentMasks{Input: common.RandomMask, Output: common.RandomMask},
+		common.SameMasks(common.RandomMask),
+		common.MatchingMasks{},
+	}
+
+	out := []Target{}
+	for _, opt := range opts {
+		opt := opt
+
+		out = append(out, Target{common.Chow, opt, func(key, seed []byte) common.Construction {
+			constr, _, _ := chow.GenerateEncryptionKeys(key, seed, opt)
+			return &constr
+		}})
+	}
+
+	for _, opt := range opts {
+		opt := opt
+
+		out = append(out, Target{common.Xiao, opt, func(key, seed []byte) common.Construction {
+			constr, _, _ := xiao.GenerateEncryptionKeys(key, seed, opt)
+			return &constr
+		}})
+	}
+
+	out = append(out, Target{common.Toy, nil, func(key, seed []byte) common.Construction {
+		constr, _, _ := toy.GenerateKeys(key, seed)
+		return &constr
+	}})
+
+	out = append(out, Target{common.Full, nil, func(key, seed []byte) common.Construction {
+		constr, _, _ := full.GenerateKeys(key, seed)
+		return &constr
+	}})
+
+	return out
+}
+
+// Measure generates target's construction from key and seed, serializes and re-parses it, and encrypts blocks blocks
+// with the parsed construction.
+func Measure(target Target, key, seed []byte, blocks int) (Result, error) {
+	res := Result{Construction: target.Type.String(), Options: common.DescribeOpts(target.Opts)}
+
+	start := time.Now()
+	constr := target.Generate(key, seed)
+	res.KeygenNanos = time.Since(start).Nanoseconds()
+
+	serialized := constr.Serialize()
+	res.SerializedSize = len(serialized)
+
+	// Parsed tables may alias the serialized construction, so count it towards the parsed construction's footprint. If
+	// they don't, the serialized construction is garbage by the second measurement and the count cancels out.
+	before := heapAlloc()
+	parsed, err := common.Load(serialized)
+	if err != nil {
+		return Result{}, err
+	}
+	if held := int64(heapAlloc()) - int64(before) + int64(len(serialized)); held > 0 {
+		res.HeapBytes = uint64(held)
+	}
+
+	block := make([]byte, 16)
+
+	start = time.Now()
+	for i := 0; i < blocks; i++ {
+		parsed.Encrypt(block, block)
+	}
+	if elapsed := time.Since(start).Seconds(); elapsed > 0 {
+		res.BlocksPerSec = float64(blocks) / elapsed
+	}
+
+	runtime.KeepAlive(parsed)
+
+	return res, nil
+}
+
+// Run measures every target in turn.
+func Run(targets []Target, key, seed []byte, blocks int) ([]Result, error) {
+	out := make([]Result, 0, len(targets))
+
+	for _, target := range targets {
+		res, err := Measure(target, key, seed, blocks)
+		if err != nil {
+			return nil, err
+		}
+
+		out = append(out, res)
+	}
+
+	return out, nil
+}
+
+// WriteJSON writes results to w as an indented JSON array.
+func WriteJSON(w io.Writer, results []Result) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(results)
+}
+
+// heapAlloc returns the number of bytes of live heap, after a garbage collection.
+func heapAlloc() uint64 {
+	stats := runtime.MemStats{}
+
+	runtime.GC()
+	runtime.ReadMemStats(&stats)
+
+	return stats.HeapAlloc
+}
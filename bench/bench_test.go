@@ -0,0 +1,47 @@
+package bench
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/OpenWhiteBox/AES/constructions/common"
+)
+
+var (
+	key  = []byte{72, 101, 108, 108, 111, 32, 87, 111, 114, 108, 100, 33, 33, 33, 33, 33}
+	seed = []byte{38, 41, 142, 156, 29, 181, 23, 194, 21, 250, 223, 183, 210, 168, 214, 145}
+)
+
+func TestRun(t *testing.T) {
+	targets := []Target{}
+	for _, target := range Targets() {
+		if target.Type == common.Toy {
+			targets = append(targets, target)
+		}
+	}
+
+	results, err := Run(targets, key, seed, 16)
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	} else if len(results) != 1 {
+		t.Fatalf("Run returned %v results, expected 1", len(results))
+	}
+
+	res := results[0]
+	if res.Construction != "toy" || res.SerializedSize == 0 || res.HeapBytes == 0 || res.BlocksPerSec == 0 {
+		t.Fatalf("Result is missing measurements: %+v", res)
+	}
+
+	buff := &bytes.Buffer{}
+	if err := WriteJSON(buff, results); err != nil {
+		t.Fatalf("WriteJSON returned error: %v", err)
+	}
+
+	parsed := []Result{}
+	if err := json.Unmarshal(buff.Bytes(), &parsed); err != nil {
+		t.Fatalf("WriteJSON wrote invalid JSON: %v", err)
+	} else if len(parsed) != 1 || parsed[0] != res {
+		t.Fatalf("Real disagrees with parsed! %+v != %+v", res, parsed)
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"crypto/rand"
+	"flag"
+	"io"
+	"os"
+
+	"github.com/OpenWhiteBox/AES/modes"
+)
+
+// cryptFlags parses the flags shared by encrypt and decrypt, and opens the input and output files.
+func cryptFlags(name string, args []string) (constrPath, privPath string, in io.ReadCloser, out io.WriteCloser, err error) {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	constr := fs.String("constr", "constr.wb", "The serialized white-box construction.")
+	priv := fs.String("priv", "constr.key", "The private key file of the construction.")
+	inPath := fs.String("in", "", "The input file. Stdin if empty.")
+	outPath := fs.String("out", "", "The output file. Stdout if empty.")
+	fs.Parse(args)
+
+	in, out = os.Stdin, os.Stdout
+
+	if *inPath != "" {
+		if in, err = os.Open(*inPath); err != nil {
+			return
+		}
+	}
+	if *outPath != "" {
+		if out, err = os.Create(*outPath); err != nil {
+			in.Close()
+			return
+		}
+	}
+
+	return *constr, *priv, in, out, nil
+}
+
+func encrypt(args []string) error {
+	constrPath, privPath, in, out, err := cryptFlags("encrypt", args)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	defer out.Close()
+
+	constr, err := readConstruction(constrPath)
+	if err != nil {
+		return err
+	}
+	_, inputMask, outputMask, err := readPrivateKey(privPath)
+	if err != nil {
+		return err
+	}
+
+	iv := make([]byte, 16)
+	rand.Read(iv)
+
+	if _, err := out.Write(iv); err != nil {
+		return err
+	}
+
+	_, err = io.Copy(modes.NewEncryptingWriter(out, constr, inputMask, outputMask, iv), in)
+	return err
+}
+
+func decrypt(args []string) error {
+	constrPath, privPath, in, out, err := cryptFlags("decrypt", args)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	defer out.Close()
+
+	constr, err := readConstruction(constrPath)
+	if err != nil {
+		return err
+	}
+	_, inputMask, outputMask, err := readPrivateKey(privPath)
+	if err != nil {
+		return err
+	}
+
+	iv := make([]byte, 16)
+	if _, err := io.ReadFull(in, iv); err != nil {
+		return err
+	}
+
+	_, err = io.Copy(out, modes.NewDecryptingReader(in, constr, inputMask, outputMask, iv))
+	return err
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"math"
+
+	"github.com/OpenWhiteBox/AES/constructions/common"
+)
+
+func inspect(args []string) error {
+	fs := flag.NewFlagSet("inspect", flag.ExitOnError)
+	constrPath := fs.String("constr", "constr.wb", "The serialized white-box construction.")
+	fs.Parse(args)
+
+	data, err := ioutil.ReadFile(*constrPath)
+	if err != nil {
+		return err
+	}
+
+	constrType, sizes, err := common.Header(data)
+	if err != nil {
+		return err
+	}
+
+	sections, err := common.Open(constrType, data, sizes...)
+	if err != nil {
+		return err
+	}
+
+	version, err := common.HeaderVersion(data)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("type:     %v\n", constrType)
+	fmt.Printf("version:  %v\n", version)
+	fmt.Printf("size:     %v bytes\n", len(data))
+	fmt.Printf("sections: %v\n", len(sections))
+
+	for i, section := range sections {
+		fmt.Printf("  %3d: %v bytes, %.4f bits/byte\n", i, len(section), entropy(section))
+	}
+
+	return nil
+}
+
+// entropy returns the Shannon entropy of the bytes of in. Well-randomized tables should be close to 8 bits per byte.
+func entropy(in []byte) (out float64) {
+	counts := [256]int{}
+	for _, b := range in {
+		counts[b]++
+	}
+
+	for _, count := range counts {
+		if count > 0 {
+			p := float64(count) / float64(len(in))
+			out -= p * math.Log2(p)
+		}
+	}
+
+	return
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"crypto/cipher"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+
+	"github.com/OpenWhiteBox/primitives/encoding"
+
+	"github.com/OpenWhiteBox/AES/constructions/common"
+	"github.com/OpenWhiteBox/AES/internal/generate"
+)
+
+// privateKey is the private output of keygen: the AES key and the masks on the construction's input and output.
+type privateKey struct {
+	Type       string        `json:"type"`
+	Key        string        `json:"key"`
+	InputMask  generate.Mask `json:"inputMask"`
+	OutputMask generate.Mask `json:"outputMask"`
+}
+
+// readPrivateKey reads a private key file written by keygen, and returns the AES key and masks in it.
+func readPrivateKey(path string) (key []byte, inputMask, outputMask encoding.BlockAffine, err error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return
+	}
+
+	priv := privateKey{}
+	if err = json.Unmarshal(data, &priv); err != nil {
+		return
+	}
+
+	if key, err = hex.DecodeString(priv.Key); err != nil {
+		return
+	} else if inputMask, err = priv.InputMask.BlockAffine(); err != nil {
+		return
+	}
+	outputMask, err = priv.OutputMask.BlockAffine()
+
+	return
+}
+
+// readConstruction reads a serialized construction of any type from disk.
+func readConstruction(path string) (cipher.Block, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return common.Load(data)
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/OpenWhiteBox/AES/constructions/common"
+	"github.com/OpenWhiteBox/AES/internal/generate"
+)
+
+func keygen(args []string) error {
+	fs := flag.NewFlagSet("keygen", flag.ExitOnError)
+	constrType := fs.String("type", "chow", "The construction to generate: chow, xiao, toy, or full.")
+	hexKey := fs.String("key", "", "A hex-encoded 128-bit AES key.")
+	hexSeed := fs.String("seed", "", "A hex-encoded seed for generation. Sampled randomly if empty.")
+	masks := fs.String("masks", "random", "The masks of a chow or xiao construction: random, identity, or matching.")
+	prefix := fs.String("out", "constr", "Prefix of the output files, <out>.wb, <out>.json, and <out>.key.")
+	fs.Parse(args)
+
+	key, err := hex.DecodeString(*hexKey)
+	if err != nil {
+		return err
+	} else if len(key) != 16 {
+		return errors.New("key must be 128 bits")
+	}
+
+	// Generation is deterministic, so we need to sample a small amount of randomness to get a random construction.
+	seed := make([]byte, 16)
+	if *hexSeed == "" {
+		rand.Read(seed)
+	} else if seed, err = hex.DecodeString(*hexSeed); err != nil {
+		return err
+	}
+
+	opts, ok := generate.MaskOpts[*masks]
+	if !ok {
+		return fmt.Errorf("unknown masks %q", *masks)
+	}
+
+	t, err := generate.ParseType(*constrType)
+	if err != nil {
+		return err
+	}
+
+	keys, err := generate.Construction(t, opts, key, seed)
+	if err != nil {
+		return err
+	}
+	serialized := keys.Construction.Serialize()
+
+	priv, err := json.MarshalIndent(privateKey{
+		Type:       *constrType,
+		Key:        hex.EncodeToString(key),
+		InputMask:  generate.NewMask(keys.InputMask),
+		OutputMask: generate.NewMask(keys.OutputMask),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	envelope := common.NewEnvelope(t, keys.Opts, seed, serialized)
+	envelope.Payload.URI = filepath.Base(*prefix + ".wb")
+
+	meta, err := envelope.Marshal()
+	if err != nil {
+		return err
+	}
+
+	// The construction and its metadata are public, but the key and masks are not.
+	if err := ioutil.WriteFile(*prefix+".wb", serialized, 0644); err != nil {
+		return err
+	} else if err := ioutil.WriteFile(*prefix+".json", meta, 0644); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(*prefix+".key", priv, 0600)
+}
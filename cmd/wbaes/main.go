@@ -0,0 +1,55 @@
+// Command wbaes generates, uses, and checks white-box AES constructions from the command line.
+//
+// Usage:
+//
+//	wbaes keygen -type chow -key <hex> [-seed <hex>] [-masks random] [-out constr]
+//	wbaes encrypt -constr constr.wb -priv constr.key [-in file] [-out file]
+//	wbaes decrypt -constr constr.wb -priv constr.key [-in file] [-out file]
+//	wbaes inspect -constr constr.wb
+//	wbaes verify -constr constr.wb -priv constr.key [-n 1000]
+//	wbaes reencode -constr constr.wb -encodings encodings.json [-generate] [-out reencoded.wb]
+//
+// keygen writes the public white-box construction to <out>.wb, its metadata envelope to <out>.json, and the private key
+// and masks to <out>.key. encrypt and decrypt compute AES-CTR with the construction, prepending a random IV to the
+// ciphertext, and read from stdin and write to stdout by default. inspect prints the layout and table statistics of a
+// construction. verify checks that a construction and its masks compute AES under the private key. reencode composes
+// two more linear encodings onto a construction without its private key, generating them first with -generate.
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+)
+
+var commands = map[string]func(args []string) error{
+	"keygen":   keygen,
+	"encrypt":  encrypt,
+	"decrypt":  decrypt,
+	"inspect":  inspect,
+	"verify":   verify,
+	"reencode": reencode,
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: wbaes <keygen|encrypt|decrypt|inspect|verify|reencode> [flags]")
+	fmt.Fprintln(os.Stderr, "Run wbaes <command> -h for the flags of a command.")
+	os.Exit(2)
+}
+
+func main() {
+	log.SetFlags(0)
+
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	command, ok := commands[os.Args[1]]
+	if !ok {
+		usage()
+	}
+
+	if err := command(os.Args[2:]); err != nil {
+		log.Fatal(err)
+	}
+}
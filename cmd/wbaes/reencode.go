@@ -0,0 +1,140 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/OpenWhiteBox/primitives/matrix"
+	"github.com/OpenWhiteBox/primitives/random"
+
+	"github.com/OpenWhiteBox/AES/constructions/chow"
+	"github.com/OpenWhiteBox/AES/constructions/full"
+	"github.com/OpenWhiteBox/AES/constructions/toy"
+	"github.com/OpenWhiteBox/AES/constructions/xiao"
+	"github.com/OpenWhiteBox/AES/internal/generate"
+)
+
+// encodings are the extra external encodings composed onto a construction by reencode. Their constants must be zero.
+type encodings struct {
+	Input  generate.Mask `json:"input"`
+	Output generate.Mask `json:"output"`
+}
+
+func reencode(args []string) error {
+	fs := flag.NewFlagSet("reencode", flag.ExitOnError)
+	constrPath := fs.String("constr", "constr.wb", "The serialized white-box construction.")
+	encPath := fs.String("encodings", "encodings.json", "The input and output encodings to compose onto it.")
+	generate := fs.Bool("generate", false, "Generate fresh random encodings and write them to -encodings first.")
+	out := fs.String("out", "reencoded.wb", "Where to write the re-encoded construction.")
+	fs.Parse(args)
+
+	if *generate {
+		if err := generateEncodings(*encPath); err != nil {
+			return err
+		}
+	}
+
+	a, b, err := readEncodings(*encPath)
+	if err != nil {
+		return err
+	}
+
+	constr, err := readConstruction(*constrPath)
+	if err != nil {
+		return err
+	}
+
+	var serialized []byte
+
+	switch constr := constr.(type) {
+	case *chow.Construction:
+		reencoded, err := constr.Reencode(a, b)
+		if err != nil {
+			return err
+		}
+		serialized = reencoded.Serialize()
+	case *xiao.Construction:
+		reencoded, err := constr.Reencode(a, b)
+		if err != nil {
+			return err
+		}
+		serialized = reencoded.Serialize()
+	case *toy.Construction:
+		reencoded, err := constr.Reencode(a, b)
+		if err != nil {
+			return err
+		}
+		serialized = reencoded.Serialize()
+	case *full.Construction:
+		reencoded, err := constr.Reencode(a, b)
+		if err != nil {
+			return err
+		}
+		serialized = reencoded.Serialize()
+	default:
+		return fmt.Errorf("can't re-encode a %T", constr)
+	}
+
+	return ioutil.WriteFile(*out, serialized, 0644)
+}
+
+// generateEncodings samples two random invertible matrices and writes them to path. They're secret: whoever holds the
+// re-encoded construction needs them to remove its masks.
+func generateEncodings(path string) error {
+	seed := make([]byte, 16)
+	rand.Read(seed)
+	rs := random.NewSource("Re-encoding", seed)
+
+	label := make([]byte, 16)
+
+	copy(label, []byte("Input"))
+	a := rs.Matrix(label, 128)
+
+	copy(label, []byte("Output"))
+	b := rs.Matrix(label, 128)
+
+	data, err := json.MarshalIndent(encodings{
+		Input:  generate.NewMask(generate.LinearMask(a)),
+		Output: generate.NewMask(generate.LinearMask(b)),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, data, 0600)
+}
+
+// readEncodings reads the linear encodings written by generateEncodings, or by hand in the same format.
+func readEncodings(path string) (a, b matrix.Matrix, err error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil, fmt.Errorf("%v doesn't exist; use -generate to create it", path)
+	} else if err != nil {
+		return nil, nil, err
+	}
+
+	enc := encodings{}
+	if err = json.Unmarshal(data, &enc); err != nil {
+		return nil, nil, err
+	}
+
+	input, err := enc.Input.BlockAffine()
+	if err != nil {
+		return nil, nil, err
+	}
+	output, err := enc.Output.BlockAffine()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if input.BlockAdditive != [16]byte{} || output.BlockAdditive != [16]byte{} {
+		return nil, nil, errors.New("encodings must be linear")
+	}
+
+	return input.Forwards, output.Forwards, nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/rand"
+	"flag"
+	"fmt"
+
+	"github.com/OpenWhiteBox/AES/modes"
+)
+
+func verify(args []string) error {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	constrPath := fs.String("constr", "constr.wb", "The serialized white-box construction.")
+	privPath := fs.String("priv", "constr.key", "The private key file of the construction.")
+	n := fs.Int("n", 1000, "The number of random blocks to check.")
+	fs.Parse(args)
+
+	constr, err := readConstruction(*constrPath)
+	if err != nil {
+		return err
+	}
+	key, inputMask, outputMask, err := readPrivateKey(*privPath)
+	if err != nil {
+		return err
+	}
+
+	real, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+	cand := modes.NewMaskedBlock(constr, inputMask, outputMask)
+
+	in, realOut, candOut := make([]byte, 16), make([]byte, 16), make([]byte, 16)
+
+	for i := 0; i < *n; i++ {
+		rand.Read(in)
+
+		real.Encrypt(realOut, in)
+		cand.Encrypt(candOut, in)
+
+		if !bytes.Equal(realOut, candOut) {
+			return fmt.Errorf("construction disagrees with AES on %x: %x != %x", in, realOut, candOut)
+		}
+	}
+
+	fmt.Printf("ok: %v blocks agree with AES\n", *n)
+	return nil
+}
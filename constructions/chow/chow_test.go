@@ -3,6 +3,11 @@ package chow
 import (
 	"bytes"
 	"crypto/aes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"testing"
 
 	"github.com/OpenWhiteBox/primitives/matrix"
@@ -73,6 +78,38 @@ func TestMaskedEncrypt(t *testing.T) {
 	}
 }
 
+func TestDeviceBinding(t *testing.T) {
+	fingerprint := []byte("device 1")
+
+	constr, inputMask, outputMask := GenerateEncryptionKeys(
+		key, seed, common.DeviceBinding{Masks: common.SameMasks(common.IdentityMask), Fingerprint: fingerprint},
+	)
+
+	// The key package ships with the unbound masks, which are the identity.
+	inputMask, outputMask = common.UnbindMasks(inputMask, outputMask, fingerprint)
+
+	real := make([]byte, 16)
+	c, _ := aes.NewCipher(key)
+	c.Encrypt(real, input)
+
+	encrypt := func(fingerprint []byte) []byte {
+		in, out := common.BindMasks(inputMask, outputMask, fingerprint)
+		inInv, _ := in.Invert()
+		outInv, _ := out.Invert()
+
+		cand := make([]byte, 16)
+		constr.Encrypt(cand, inInv.Mul(matrix.Row(input)))
+
+		return outInv.Mul(matrix.Row(cand))
+	}
+
+	if cand := encrypt(fingerprint); !bytes.Equal(real, cand) {
+		t.Fatalf("Real disagrees with result! %x != %x", real, cand)
+	} else if cand := encrypt([]byte("device 2")); bytes.Equal(real, cand) {
+		t.Fatal("Construction works on a different device!")
+	}
+}
+
 func TestEncrypt(t *testing.T) {
 	for n, vec := range test_vectors.GetAESVectors(testing.Short()) {
 		constr, inputMask, outputMask := GenerateEncryptionKeys(
@@ -119,6 +156,37 @@ func TestDecrypt(t *testing.T) {
 	}
 }
 
+func TestReencode(t *testing.T) {
+	constr1, inputMask, outputMask := GenerateEncryptionKeys(key, seed, common.MatchingMasks{})
+	_, a, b := GenerateEncryptionKeys(key, key, common.IndependentMasks{common.RandomMask, common.RandomMask})
+
+	// Distributors only have the serialized construction.
+	parsed, err := Parse(constr1.Serialize())
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+
+	constr2, err := parsed.Reencode(a, b)
+	if err != nil {
+		t.Fatalf("Reencode returned error: %v", err)
+	}
+
+	inputInv, _ := inputMask.Compose(a).Invert()
+	outputInv, _ := b.Compose(outputMask).Invert()
+
+	cand, real := make([]byte, 16), make([]byte, 16)
+
+	constr2.Encrypt(cand, inputInv.Mul(matrix.Row(input)))
+	copy(cand, outputInv.Mul(matrix.Row(cand)))
+
+	c, _ := aes.NewCipher(key)
+	c.Encrypt(real, input)
+
+	if !bytes.Equal(real, cand) {
+		t.Fatalf("Real disagrees with result! %x != %x", real, cand)
+	}
+}
+
 func TestPersistence(t *testing.T) {
 	constr1, _, _ := GenerateEncryptionKeys(key, seed, common.IndependentMasks{common.RandomMask, common.RandomMask})
 
@@ -140,6 +208,138 @@ func TestPersistence(t *testing.T) {
 	}
 }
 
+// harness is a C program which encrypts the hex-encoded block in its first argument with an exported construction.
+const harness = `#include <stdio.h>
+
+#include "constr.h"
+
+int main(int argc, char **argv) {
+	uint8_t block[16];
+	unsigned int b;
+	int i;
+
+	if (argc != 2) {
+		return 1;
+	}
+
+	for (i = 0; i < 16; i++) {
+		sscanf(argv[1] + 2 * i, "%2x", &b);
+		block[i] = (uint8_t) b;
+	}
+
+	constr_encrypt(block, block);
+
+	for (i = 0; i < 16; i++) {
+		printf("%02x", block[i]);
+	}
+	printf("\n");
+
+	return 0;
+}
+`
+
+func TestExportC(t *testing.T) {
+	cc, err := exec.LookPath("cc")
+	if err != nil {
+		t.Skip("No C compiler available.")
+	}
+
+	dir, err := ioutil.TempDir("", "chow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	constr, _, _ := GenerateEncryptionKeys(key, seed, common.IndependentMasks{common.RandomMask, common.RandomMask})
+
+	header, _ := os.Create(filepath.Join(dir, "constr.h"))
+	source, _ := os.Create(filepath.Join(dir, "constr.c"))
+
+	err = constr.ExportC(header, source, "constr")
+	header.Close()
+	source.Close()
+
+	if err != nil {
+		t.Fatalf("ExportC returned error: %v", err)
+	} else if err := ioutil.WriteFile(filepath.Join(dir, "main.c"), []byte(harness), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	bin := filepath.Join(dir, "harness")
+	build := exec.Command(cc, "-o", bin, filepath.Join(dir, "main.c"), filepath.Join(dir, "constr.c"))
+	if out, err := build.CombinedOutput(); err != nil {
+		t.Fatalf("Failed to compile exported construction: %v\n%s", err, out)
+	}
+
+	out, err := exec.Command(bin, fmt.Sprintf("%x", input)).Output()
+	if err != nil {
+		t.Fatalf("Harness returned error: %v", err)
+	}
+
+	real := make([]byte, 16)
+	constr.Encrypt(real, input)
+
+	if cand := string(bytes.TrimSpace(out)); cand != fmt.Sprintf("%x", real) {
+		t.Fatalf("Real disagrees with C! %x != %v", real, cand)
+	}
+}
+
+// goHarness is a program which encrypts the hex-encoded block in its first argument with an exported Go package.
+const goHarness = `package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+)
+
+func main() {
+	block, _ := hex.DecodeString(os.Args[1])
+	Encrypt(block, block)
+	fmt.Printf("%x\n", block)
+}
+`
+
+func TestExportGo(t *testing.T) {
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("No Go toolchain available.")
+	}
+
+	dir, err := ioutil.TempDir("", "chow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	constr, _, _ := GenerateEncryptionKeys(key, seed, common.IndependentMasks{common.RandomMask, common.RandomMask})
+
+	source, _ := os.Create(filepath.Join(dir, "constr.go"))
+	err = constr.ExportGo(source, "main")
+	source.Close()
+
+	if err != nil {
+		t.Fatalf("ExportGo returned error: %v", err)
+	} else if err := ioutil.WriteFile(filepath.Join(dir, "main.go"), []byte(goHarness), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	run := exec.Command(goTool, "run", "main.go", "constr.go", fmt.Sprintf("%x", input))
+	run.Dir = dir
+
+	out, err := run.CombinedOutput()
+	if err != nil {
+		t.Fatalf("Failed to run exported construction: %v\n%s", err, out)
+	}
+
+	real := make([]byte, 16)
+	constr.Encrypt(real, input)
+
+	if cand := string(bytes.TrimSpace(out)); cand != fmt.Sprintf("%x", real) {
+		t.Fatalf("Real disagrees with Go! %x != %v", real, cand)
+	}
+}
+
 func BenchmarkGenerateEncryptionKeys(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		constr, _, _ := GenerateEncryptionKeys(key, seed, common.IndependentMasks{common.RandomMask, common.RandomMask})
@@ -0,0 +1,394 @@
+package chow
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"go/token"
+	"io"
+
+	"github.com/OpenWhiteBox/primitives/table"
+
+	"github.com/OpenWhiteBox/AES/constructions/common"
+	"github.com/OpenWhiteBox/AES/flat"
+)
+
+// cHeader is the C header of an exported construction. %[1]s is the name of the construction.
+const cHeader = `#ifndef %[1]s_H
+#define %[1]s_H
+
+#include <stdint.h>
+
+/* Pushes the block src through the white-box tables as an encryption construction and writes the result to dst. */
+void %[1]s_encrypt(uint8_t dst[16], const uint8_t src[16]);
+
+/* Pushes the block src through the white-box tables as a decryption construction and writes the result to dst. */
+void %[1]s_decrypt(uint8_t dst[16], const uint8_t src[16]);
+
+#endif
+`
+
+// cSource is the evaluation code of an exported construction, which follows the tables. It mirrors Construction.crypt.
+const cSource = `static const uint8_t shift_rows[16] = {0, 5, 10, 15, 4, 9, 14, 3, 8, 13, 2, 7, 12, 1, 6, 11};
+static const uint8_t unshift_rows[16] = {0, 13, 10, 7, 4, 1, 14, 11, 8, 5, 2, 15, 12, 9, 6, 3};
+
+static void permute(uint8_t block[16], const uint8_t perm[16]) {
+	uint8_t temp[16];
+	int i;
+
+	for (i = 0; i < 16; i++) {
+		temp[i] = block[perm[i]];
+	}
+	memcpy(block, temp, 16);
+}
+
+/* XORs the bytes a and b with the nibble XOR tables at xor_table. The table for the low nibble is stride tables after
+ * the table for the high nibble. */
+static uint8_t squash(const uint8_t *xor_table, int stride, uint8_t a, uint8_t b) {
+	uint8_t high = (a & 0xf0) | ((b & 0xf0) >> 4);
+	uint8_t low = ((a & 0x0f) << 4) | (b & 0x0f);
+
+	return (xor_table[high] << 4) | xor_table[256 * stride + low];
+}
+
+/* Applies a block matrix: expands each byte of block into a block with mask, then XORs the blocks together. */
+static void block_matrix(const uint8_t *mask, const uint8_t *xor_tables, uint8_t block[16]) {
+	uint8_t expanded[16][16];
+	int i, pos;
+
+	for (i = 0; i < 16; i++) {
+		memcpy(expanded[i], &mask[16 * (256 * i + block[i])], 16);
+	}
+
+	memcpy(block, expanded[0], 16);
+	for (i = 1; i < 16; i++) {
+		for (pos = 0; pos < 16; pos++) {
+			block[pos] = squash(&xor_tables[256 * (2 * pos * 15 + i - 1)], 15, block[pos], expanded[i][pos]);
+		}
+	}
+}
+
+/* Applies one half of a round to the word at word: expands each byte into a word with step, then XORs them. */
+static void step(const uint8_t *step_tables, const uint8_t *xor_tables, uint8_t word[4]) {
+	uint8_t expanded[4][4];
+	int i, pos;
+
+	for (i = 0; i < 4; i++) {
+		memcpy(expanded[i], &step_tables[4 * (256 * i + word[i])], 4);
+	}
+
+	memcpy(word, expanded[0], 4);
+	for (i = 1; i < 4; i++) {
+		for (pos = 0; pos < 4; pos++) {
+			word[pos] = squash(&xor_tables[256 * (2 * pos * 3 + i - 1)], 3, word[pos], expanded[i][pos]);
+		}
+	}
+}
+
+static void wb_crypt(uint8_t dst[16], const uint8_t src[16], const uint8_t perm[16]) {
+	int round, pos;
+
+	memmove(dst, src, 16);
+
+	block_matrix(input_mask, input_xor, dst);
+
+	for (round = 0; round < 9; round++) {
+		permute(dst, perm);
+
+		for (pos = 0; pos < 16; pos += 4) {
+			step(&tbox_tyi[4 * 256 * (16 * round + pos)], &high_xor[256 * 3 * (32 * round + 2 * pos)], &dst[pos]);
+			step(&mb_inverse[4 * 256 * (16 * round + pos)], &low_xor[256 * 3 * (32 * round + 2 * pos)], &dst[pos]);
+		}
+	}
+
+	permute(dst, perm);
+
+	block_matrix(output_mask, output_xor, dst);
+}
+
+void %[1]s_encrypt(uint8_t dst[16], const uint8_t src[16]) {
+	wb_crypt(dst, src, shift_rows);
+}
+
+void %[1]s_decrypt(uint8_t dst[16], const uint8_t src[16]) {
+	wb_crypt(dst, src, unshift_rows);
+}
+`
+
+// goHeader is the start of an exported Go package, before the tables. %[1]s is the name of the package.
+const goHeader = `// Code generated by chow.ExportGo. DO NOT EDIT.
+
+// Package %[1]s is a white-box AES construction with its tables compiled in.
+package %[1]s
+
+`
+
+// goSource is the evaluation code of an exported Go package, which follows the tables. It mirrors the evaluation of a
+// Chow key package in the flat package, but over string constants, which are read-only and don't allocate when sliced.
+const goSource = `var (
+	shiftRows   = [16]int{0, 5, 10, 15, 4, 9, 14, 3, 8, 13, 2, 7, 12, 1, 6, 11}
+	unShiftRows = [16]int{0, 13, 10, 7, 4, 1, 14, 11, 8, 5, 2, 15, 12, 9, 6, 3}
+)
+
+// Encrypt pushes the first block in src through the white-box tables as an encryption construction and writes the
+// result to dst. Dst and src may point at the same memory.
+func Encrypt(dst, src []byte) {
+	crypt(dst, src, &shiftRows)
+}
+
+// Decrypt pushes the first block in src through the white-box tables as a decryption construction and writes the
+// result to dst. Dst and src may point at the same memory.
+func Decrypt(dst, src []byte) {
+	crypt(dst, src, &unShiftRows)
+}
+
+func crypt(dst, src []byte, shift *[16]int) {
+	state := [16]byte{}
+	copy(state[:], src[:16])
+
+	blockMatrix(inputMask, inputXOR, &state)
+
+	for round := 0; round < 9; round++ {
+		permute(&state, shift)
+
+		for pos := 0; pos < 16; pos += 4 {
+			word := state[pos : pos+4]
+
+			step(tboxTyi[4*256*(16*round+pos):], highXOR[256*3*(32*round+2*pos):], word)
+			step(mbInverse[4*256*(16*round+pos):], lowXOR[256*3*(32*round+2*pos):], word)
+		}
+	}
+
+	permute(&state, shift)
+
+	blockMatrix(outputMask, outputXOR, &state)
+
+	copy(dst[:16], state[:])
+}
+
+func permute(state *[16]byte, perm *[16]int) {
+	temp := *state
+	for i, j := range perm {
+		state[i] = temp[j]
+	}
+}
+
+// squash XORs the bytes a and b with the nibble XOR tables at xor. The table for the low nibble is stride tables after
+// the table for the high nibble.
+func squash(xor string, stride int, a, b byte) byte {
+	high := a&0xf0 | b>>4
+	low := a<<4 | b&0x0f
+
+	return xor[high]<<4 | xor[256*stride+int(low)]
+}
+
+// blockMatrix expands each byte of state into a block with mask, and then XORs the blocks together.
+func blockMatrix(mask, xor string, state *[16]byte) {
+	expanded := [16][16]byte{}
+	for i := 0; i < 16; i++ {
+		copy(expanded[i][:], mask[16*(256*i+int(state[i])):])
+	}
+
+	*state = expanded[0]
+	for i := 1; i < 16; i++ {
+		for pos := 0; pos < 16; pos++ {
+			state[pos] = squash(xor[256*(2*pos*15+i-1):], 15, state[pos], expanded[i][pos])
+		}
+	}
+}
+
+// step expands each byte of word into a word with tables, and then XORs the words together.
+func step(tables, xor string, word []byte) {
+	expanded := [4][4]byte{}
+	for i := 0; i < 4; i++ {
+		copy(expanded[i][:], tables[4*(256*i+int(word[i])):])
+	}
+
+	copy(word, expanded[0][:])
+	for i := 1; i < 4; i++ {
+		for pos := 0; pos < 4; pos++ {
+			word[pos] = squash(xor[256*(2*pos*3+i-1):], 3, word[pos], expanded[i][pos])
+		}
+	}
+}
+`
+
+// goConsts is the name of each table in the Go source, in the order of flatTables.
+var goConsts = []string{
+	"inputMask", "inputXOR", "tboxTyi", "highXOR", "mbInverse", "lowXOR", "outputMask", "outputXOR",
+}
+
+// cArrays is the name of each table in the C source, in the order of flatTables.
+var cArrays = []string{
+	"input_mask", "input_xor", "tbox_tyi", "high_xor", "mb_inverse", "low_xor", "output_mask", "output_xor",
+}
+
+// ExportC writes the construction as C: a header declaring name_encrypt and name_decrypt is written to header, and the
+// tables along with a dependency-free implementation of those functions are written to source. The source file
+// includes the header as "name.h". name must be a valid C identifier.
+//
+// Like Encrypt and Decrypt, which function computes AES depends on whether the construction was generated for
+// encryption or decryption.
+func (constr *Construction) ExportC(header, source io.Writer, name string) error {
+	if !validIdentifier(name) {
+		return errors.New("name must be a valid C identifier")
+	}
+
+	if _, err := fmt.Fprintf(header, cHeader, name); err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(source)
+	fmt.Fprintf(w, "#include <stdint.h>\n#include <string.h>\n\n#include \"%s.h\"\n\n", name)
+
+	for i, t := range constr.flatTables() {
+		writeCArray(w, cArrays[i], t)
+	}
+
+	fmt.Fprintf(w, cSource, name)
+
+	return w.Flush()
+}
+
+// ExportGo writes the construction as the source of a standalone Go package named pkg, with the tables as string
+// constants and functions Encrypt and Decrypt which evaluate over them. The package imports nothing, so it can be
+// compiled into an application in place of loading a serialized construction at runtime. pkg must be a valid Go
+// identifier.
+//
+// Like ExportC, which function computes AES depends on whether the construction was generated for encryption or
+// decryption.
+func (constr *Construction) ExportGo(w io.Writer, pkg string) error {
+	if !token.IsIdentifier(pkg) {
+		return errors.New("pkg must be a valid Go identifier")
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, goHeader, pkg)
+
+	bw.WriteString("const (\n")
+	for i, t := range constr.flatTables() {
+		writeGoString(bw, goConsts[i], t)
+	}
+	bw.WriteString(")\n\n")
+
+	bw.WriteString(goSource)
+
+	return bw.Flush()
+}
+
+// Flatten serializes the construction into the flat key-package format of the flat package.
+func (constr *Construction) Flatten() []byte {
+	return flat.Build(flat.Chow, constr.flatTables()...)
+}
+
+// flatTables expands every table of the construction into a flat array, in the order they're applied.
+func (constr *Construction) flatTables() [][]byte {
+	return [][]byte{
+		blockMatrixTables(constr.InputMask),
+		nibbleTables(32*15, func(loc int) table.Nibble { return constr.InputXORTables[loc/15][loc%15] }),
+
+		wordTables(9*16, func(loc int) table.Word { return constr.TBoxTyiTable[loc/16][loc%16] }),
+		nibbleTables(9*32*3, func(loc int) table.Nibble { return constr.HighXORTable[loc/(32*3)][loc/3%32][loc%3] }),
+
+		wordTables(9*16, func(loc int) table.Word { return constr.MBInverseTable[loc/16][loc%16] }),
+		nibbleTables(9*32*3, func(loc int) table.Nibble { return constr.LowXORTable[loc/(32*3)][loc/3%32][loc%3] }),
+
+		blockMatrixTables(constr.TBoxOutputMask),
+		nibbleTables(32*15, func(loc int) table.Nibble { return constr.OutputXORTables[loc/15][loc%15] }),
+	}
+}
+
+// validIdentifier returns true if name is a valid C identifier.
+func validIdentifier(name string) bool {
+	for i, c := range name {
+		letter := c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+		digit := '0' <= c && c <= '9'
+
+		if !letter && !(digit && i > 0) {
+			return false
+		}
+	}
+
+	return name != ""
+}
+
+// writeCArray writes data as a static C array of bytes.
+func writeCArray(w *bufio.Writer, name string, data []byte) {
+	fmt.Fprintf(w, "static const uint8_t %s[%d] = {\n", name, len(data))
+
+	for i, b := range data {
+		if i%16 == 0 {
+			w.WriteString("\t")
+		}
+		fmt.Fprintf(w, "0x%02x,", b)
+		if i%16 == 15 || i == len(data)-1 {
+			w.WriteString("\n")
+		} else {
+			w.WriteString(" ")
+		}
+	}
+
+	w.WriteString("};\n\n")
+}
+
+// writeGoString writes data as a Go string constant inside a const block, with the name padded as gofmt would align it.
+// It's on one line: a concatenation of many short literals is much slower to compile.
+func writeGoString(w *bufio.Writer, name string, data []byte) {
+	fmt.Fprintf(w, "\t%-10s = \"", name)
+
+	for _, b := range data {
+		fmt.Fprintf(w, "\\x%02x", b)
+	}
+
+	w.WriteString("\"\n")
+}
+
+// blockMatrixTables expands the sixteen tables of a block matrix into a flat array, indexed by [position][input][byte].
+func blockMatrixTables(m [16]table.Block) []byte {
+	out := make([]byte, 16*256*16)
+
+	common.SerializeTables(out, 256*16, 16, func(pos int) []byte {
+		t := make([]byte, 0, 256*16)
+		for i := 0; i < 256; i++ {
+			row := m[pos].Get(byte(i))
+			t = append(t, row[:]...)
+		}
+
+		return t
+	})
+
+	return out
+}
+
+// wordTables expands n word tables into a flat array, indexed by [table][input][byte].
+func wordTables(n int, get func(int) table.Word) []byte {
+	out := make([]byte, n*256*4)
+
+	common.SerializeTables(out, 256*4, n, func(loc int) []byte {
+		t := make([]byte, 0, 256*4)
+		for i := 0; i < 256; i++ {
+			word := get(loc).Get(byte(i))
+			t = append(t, word[:]...)
+		}
+
+		return t
+	})
+
+	return out
+}
+
+// nibbleTables expands n nibble tables into a flat array, indexed by [table][input]. Each entry is one nibble.
+func nibbleTables(n int, get func(int) table.Nibble) []byte {
+	out := make([]byte, n*256)
+
+	common.SerializeTables(out, 256, n, func(loc int) []byte {
+		t := make([]byte, 256)
+		for i := 0; i < 256; i++ {
+			t[i] = get(loc).Get(byte(i))
+		}
+
+		return t
+	})
+
+	return out
+}
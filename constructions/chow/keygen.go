@@ -11,8 +11,9 @@ import (
 )
 
 func generateKeys(rs *random.Source, opts common.KeyGenerationOpts, out *Construction, inputMask, outputMask *matrix.Matrix, shift func(int) int, skinny func(int) table.Byte, wide func(int, int) table.Word) {
-	// Generate input and output encodings.
+	// Generate input and output encodings, and then switch to the source for internal encodings.
 	common.GenerateMasks(rs, opts, inputMask, outputMask)
+	rs = common.KeygenSource(rs, opts)
 
 	// Generate the Input Mask slices and XOR tables.
 	for pos := 0; pos < 16; pos++ {
@@ -93,7 +94,8 @@ func generateKeys(rs *random.Source, opts common.KeyGenerationOpts, out *Constru
 
 // GenerateEncryptionKeys creates a white-boxed version of AES with given key for encryption, with any non-determinism
 // generated by seed. Opts specifies what type of input and output masks we put on the construction and should be in
-// common.{IndependentMasks, SameMasks, MatchingMasks}.
+// common.{IndependentMasks, SameMasks, MatchingMasks}, or a common.DeviceBinding or common.Watermark wrapping one of
+// them.
 func GenerateEncryptionKeys(key, seed []byte, opts common.KeyGenerationOpts) (out Construction, inputMask, outputMask matrix.Matrix) {
 	rs := random.NewSource("Chow Encryption", seed)
 
@@ -123,7 +125,8 @@ func GenerateEncryptionKeys(key, seed []byte, opts common.KeyGenerationOpts) (ou
 
 // GenerateDecryptionKeys creates a white-boxed version of AES with given key for decryption, with any non-determinism
 // generated by seed. Opts specifies what type of input and output masks we put on the construction and should be in
-// common.{IndependentMasks, SameMasks, MatchingMasks}.
+// common.{IndependentMasks, SameMasks, MatchingMasks}, or a common.DeviceBinding or common.Watermark wrapping one of
+// them.
 func GenerateDecryptionKeys(key, seed []byte, opts common.KeyGenerationOpts) (out Construction, inputMask, outputMask matrix.Matrix) {
 	rs := random.NewSource("Chow Decryption", seed)
 
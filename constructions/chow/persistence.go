@@ -1,7 +1,7 @@
 package chow
 
 import (
-	"errors"
+	"crypto/ed25519"
 
 	"github.com/OpenWhiteBox/primitives/table"
 
@@ -9,64 +9,95 @@ import (
 )
 
 const (
-	fullSize = 770048
-
 	maskTableSize = 256 * 16
 	stepTableSize = 256 * 4
 	xorTableSize  = 256 / 2
+
+	blockMatrixSize = 16*maskTableSize + 32*15*xorTableSize
+	stepTablesSize  = 9 * 16 * stepTableSize
+	xorTablesSize   = 9 * 32 * 3 * xorTableSize
 )
 
+// sectionSizes is the size of each section of a serialized construction, in the order they're written.
+var sectionSizes = []int{
+	blockMatrixSize, stepTablesSize, xorTablesSize, stepTablesSize, xorTablesSize, blockMatrixSize,
+}
+
+func init() {
+	common.Register(common.Chow, func(in []byte) (common.Construction, error) {
+		constr, err := Parse(in)
+		if err != nil {
+			return nil, err
+		}
+
+		return &constr, nil
+	})
+}
+
 // Serialize serializes a white-box construction into a byte slice.
 func (constr *Construction) Serialize() []byte {
-	out, base := make([]byte, fullSize), 0
+	inputMask, outputMask := make([]byte, blockMatrixSize), make([]byte, blockMatrixSize)
+	tboxTyi, mbInverse := make([]byte, stepTablesSize), make([]byte, stepTablesSize)
+	highXOR, lowXOR := make([]byte, xorTablesSize), make([]byte, xorTablesSize)
 
 	// Input Mask
-	base += common.SerializeBlockMatrix(out[base:], constr.InputMask, constr.InputXORTables)
+	common.SerializeBlockMatrix(inputMask, constr.InputMask, constr.InputXORTables)
 
 	// First half of round
-	base += serializeStepTables(out[base:], constr.TBoxTyiTable)
-	base += serializeXORTables(out[base:], constr.HighXORTable)
+	serializeStepTables(tboxTyi, constr.TBoxTyiTable)
+	serializeXORTables(highXOR, constr.HighXORTable)
 
 	// Second half of round
-	base += serializeStepTables(out[base:], constr.MBInverseTable)
-	base += serializeXORTables(out[base:], constr.LowXORTable)
+	serializeStepTables(mbInverse, constr.MBInverseTable)
+	serializeXORTables(lowXOR, constr.LowXORTable)
 
 	// Output Mask
-	common.SerializeBlockMatrix(out[base:], constr.TBoxOutputMask, constr.OutputXORTables)
+	common.SerializeBlockMatrix(outputMask, constr.TBoxOutputMask, constr.OutputXORTables)
 
-	return out
+	return common.Seal(common.Chow, inputMask, tboxTyi, highXOR, mbInverse, lowXOR, outputMask)
 }
 
-// Parse parses a byte array into a white-box construction. It returns an error if the byte array isn't long enough.
+// Parse parses a byte array into a white-box construction. It returns an error if the byte array isn't a valid,
+// uncorrupted serialization of a Chow construction.
 func Parse(in []byte) (constr Construction, err error) {
-	var rest []byte
-
-	constr.InputMask, constr.InputXORTables, rest = common.ParseBlockNibbleMatrix(in)
+	sections, err := common.Open(common.Chow, in, sectionSizes...)
+	if err != nil {
+		return
+	}
 
-	constr.TBoxTyiTable, rest = parseStepTables(rest)
-	constr.HighXORTable, rest = parseXORTables(rest)
+	constr.InputMask, constr.InputXORTables, _ = common.ParseBlockNibbleMatrix(sections[0])
 
-	constr.MBInverseTable, rest = parseStepTables(rest)
-	constr.LowXORTable, rest = parseXORTables(rest)
+	constr.TBoxTyiTable, _ = parseStepTables(sections[1])
+	constr.HighXORTable, _ = parseXORTables(sections[2])
 
-	constr.TBoxOutputMask, constr.OutputXORTables, rest = common.ParseBlockNibbleMatrix(rest)
+	constr.MBInverseTable, _ = parseStepTables(sections[3])
+	constr.LowXORTable, _ = parseXORTables(sections[4])
 
-	if rest == nil {
-		err = errors.New("Parsing the key failed!")
-	}
+	constr.TBoxOutputMask, constr.OutputXORTables, _ = common.ParseBlockNibbleMatrix(sections[5])
 
 	return
 }
 
-func serializeStepTables(dst []byte, t [9][16]table.Word) int {
-	base := 0
-	for _, round := range t {
-		for _, pos := range round {
-			base += copy(dst[base:], table.SerializeWord(pos))
-		}
+// SerializeSigned serializes a white-box construction into a byte slice, and signs it with key.
+func (constr *Construction) SerializeSigned(key ed25519.PrivateKey) []byte {
+	return common.Sign(constr.Serialize(), key)
+}
+
+// ParseSigned parses a signed byte slice into a white-box construction. It returns an error if the signature doesn't
+// verify under key, or if the byte slice isn't a valid, uncorrupted serialization of a Chow construction.
+func ParseSigned(in []byte, key ed25519.PublicKey) (constr Construction, err error) {
+	body, err := common.Verify(in, key)
+	if err != nil {
+		return
 	}
 
-	return base
+	return Parse(body)
+}
+
+func serializeStepTables(dst []byte, t [9][16]table.Word) int {
+	return common.SerializeTables(dst, stepTableSize, 9*16, func(loc int) []byte {
+		return table.SerializeWord(t[loc/16][loc%16])
+	})
 }
 
 func parseStepTables(in []byte) (out [9][16]table.Word, rest []byte) {
@@ -85,16 +116,9 @@ func parseStepTables(in []byte) (out [9][16]table.Word, rest []byte) {
 }
 
 func serializeXORTables(dst []byte, t [9][32][3]table.Nibble) int {
-	base := 0
-	for _, round := range t {
-		for _, pos := range round {
-			for _, gate := range pos {
-				base += copy(dst[base:], table.SerializeNibble(gate))
-			}
-		}
-	}
-
-	return base
+	return common.SerializeTables(dst, xorTableSize, 9*32*3, func(loc int) []byte {
+		return table.SerializeNibble(t[loc/(32*3)][loc/3%32][loc%3])
+	})
 }
 
 func parseXORTables(in []byte) (out [9][32][3]table.Nibble, rest []byte) {
@@ -0,0 +1,193 @@
+package chow
+
+import (
+	"errors"
+
+	"github.com/OpenWhiteBox/primitives/encoding"
+	"github.com/OpenWhiteBox/primitives/matrix"
+
+	"github.com/OpenWhiteBox/AES/constructions/common"
+)
+
+// nibbleTable is a nibble encoding given by its table of values. It implements encoding.Nibble.
+type nibbleTable [16]byte
+
+func (nt nibbleTable) Encode(i byte) byte { return nt[i] }
+
+func (nt nibbleTable) Decode(i byte) byte {
+	for j, v := range nt {
+		if v == i {
+			return byte(j)
+		}
+	}
+
+	return 0
+}
+
+// blockTable is a Block table given by its table of values. It implements table.Block.
+type blockTable [256][16]byte
+
+func (bt *blockTable) Get(i byte) [16]byte { return bt[i] }
+
+// Reencode composes additional linear external encodings onto the construction, without knowledge of its key or masks.
+// The returned construction computes b(constr(a(x))): if constr has masks inputMask and outputMask, the returned
+// construction has masks inputMask.Compose(a) and b.Compose(outputMask). a and b must be invertible 128x128 matrices.
+//
+// The input and output layers of the construction are rebuilt. The linear part of each is recovered from the tables,
+// up to the nibble encodings on its output, and composed with a or b. The internal encodings of the rebuilt layers are
+// identities: the recovery shows that anyone with the tables can already strip them.
+func (constr *Construction) Reencode(a, b matrix.Matrix) (Construction, error) {
+	if _, ok := a.Invert(); !ok {
+		return Construction{}, errors.New("input encoding isn't invertible")
+	} else if _, ok := b.Invert(); !ok {
+		return Construction{}, errors.New("output encoding isn't invertible")
+	}
+
+	out := *constr
+
+	rows, enc, err := recoverLinearLayer(constr.inputLayer)
+	if err != nil {
+		return Construction{}, err
+	}
+
+	linear := matrix.Matrix{}
+	for _, row := range rows {
+		linear = append(linear, matrix.Row(append([]byte{}, row[:]...)))
+	}
+	linear = linear.Compose(a)
+
+	for pos := 0; pos < 16; pos++ {
+		out.InputMask[pos] = common.BlockMatrix{Linear: linear, Position: pos}
+	}
+	out.InputXORTables = common.BlockNibbleXORTables(
+		identityNibble, identityNibble, func(position int) encoding.Nibble { return enc[position] },
+	)
+
+	for pos, contribution := range recoverContributions(constr.outputLayer) {
+		t := &blockTable{}
+		for i := range t {
+			copy(t[i][:], b.Mul(matrix.Row(contribution[i][:])))
+		}
+
+		out.TBoxOutputMask[pos] = t
+	}
+	out.OutputXORTables = common.BlockNibbleXORTables(
+		identityNibble, identityNibble, func(position int) encoding.Nibble { return encoding.IdentityByte{} },
+	)
+
+	return out, nil
+}
+
+func identityNibble(position, subPosition int) encoding.Nibble { return encoding.IdentityByte{} }
+
+// inputLayer computes the input mask and the XOR tables after it.
+func (constr *Construction) inputLayer(in [16]byte) (out [16]byte) {
+	stretched := constr.expandBlock(constr.InputMask, in[:])
+	constr.InputXORTables.SquashBlocks(stretched, out[:])
+
+	return
+}
+
+// outputLayer computes the final T-Boxes with the output mask and the XOR tables after them.
+func (constr *Construction) outputLayer(in [16]byte) (out [16]byte) {
+	stretched := constr.expandBlock(constr.TBoxOutputMask, in[:])
+	constr.OutputXORTables.SquashBlocks(stretched, out[:])
+
+	return
+}
+
+// nibble returns the nibble at the given position of a block, in the order of the XOR tables: even positions are the
+// high nibbles of bytes.
+func nibble(block [16]byte, position int) byte {
+	if position%2 == 0 {
+		return block[position/2] >> 4
+	}
+	return block[position/2] & 0x0f
+}
+
+// recoverLinearLayer decomposes f, which must be a linear map followed by a bijection on each nibble of the output,
+// into a linear map and nibble encodings. f(x) is the encoding of rows*x, with each nibble encoded separately.
+//
+// The nibble encodings are only determined up to a linear transformation, so one is fixed per nibble: the first four
+// unit vectors that are linearly independent under that nibble of f.
+func recoverLinearLayer(f func([16]byte) [16]byte) (rows [128][16]byte, enc [32]nibbleTable, err error) {
+	eval := func(bits []int) [16]byte {
+		in := [16]byte{}
+		for _, bit := range bits {
+			in[bit/8] ^= 1 << uint(bit%8)
+		}
+		return f(in)
+	}
+
+	units := [128][16]byte{}
+	for j := range units {
+		units[j] = eval([]int{j})
+	}
+
+	for pos := 0; pos < 32; pos++ {
+		// coords maps each value of this nibble of f on the span of the basis to its coordinates in the basis. Since
+		// the nibble encoding is a bijection, equal outputs mean equal inputs to it.
+		basis, coords := []int{}, map[byte]byte{nibble(eval(nil), pos): 0}
+
+		for j := 0; j < 128; j++ {
+			c, ok := coords[nibble(units[j], pos)]
+			if !ok {
+				if len(basis) == 4 {
+					return rows, enc, errors.New("layer isn't linear under its nibble encodings")
+				}
+
+				c = 1 << uint(len(basis))
+				for prev := byte(0); prev < c; prev++ {
+					bits := []int{j}
+					for k, bit := range basis {
+						if prev>>uint(k)&1 == 1 {
+							bits = append(bits, bit)
+						}
+					}
+
+					coords[nibble(eval(bits), pos)] = prev | c
+				}
+				basis = append(basis, j)
+			}
+
+			// Bit k of this nibble of the linear part is coordinate k. High nibbles are the top half of their byte.
+			for k := uint(0); k < 4; k++ {
+				row := 8*(pos/2) + 4*(1-pos%2) + int(k)
+				rows[row][j/8] |= (c >> k & 1) << uint(j%8)
+			}
+		}
+
+		if len(basis) != 4 {
+			return rows, enc, errors.New("layer isn't invertible")
+		}
+
+		for v, c := range coords {
+			enc[pos][c] = v
+		}
+	}
+
+	return rows, enc, nil
+}
+
+// recoverContributions decomposes f, which must be a XOR of one function of each byte of its input, into those
+// functions. The decomposition isn't unique--constants can be moved between positions--but the contributions always
+// XOR to f.
+func recoverContributions(f func([16]byte) [16]byte) (out [16][256][16]byte) {
+	base := f([16]byte{})
+
+	for pos := 0; pos < 16; pos++ {
+		for x := 0; x < 256; x++ {
+			in := [16]byte{}
+			in[pos] = byte(x)
+
+			out[pos][x] = f(in)
+			if pos > 0 {
+				for i := range base {
+					out[pos][x][i] ^= base[i]
+				}
+			}
+		}
+	}
+
+	return
+}
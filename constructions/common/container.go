@@ -0,0 +1,165 @@
+package common
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// ContainerVersion is the version of the container format written by Seal.
+const ContainerVersion = 1
+
+// containerMagic is the first four bytes of every container.
+var containerMagic = []byte("WBOX")
+
+// ConstructionType identifies which white-box construction a container holds.
+type ConstructionType byte
+
+const (
+	Chow ConstructionType = iota + 1
+	Xiao
+	Toy
+	Full
+	XiaoPiece
+)
+
+func (ct ConstructionType) String() string {
+	switch ct {
+	case Chow:
+		return "chow"
+	case Xiao:
+		return "xiao"
+	case Toy:
+		return "toy"
+	case Full:
+		return "full"
+	case XiaoPiece:
+		return "xiao-piece"
+	default:
+		return fmt.Sprintf("ConstructionType(%d)", byte(ct))
+	}
+}
+
+// Seal packs the serialized sections of a construction into a container. The layout is, with all integers big-endian:
+//
+//	magic        [4]byte  "WBOX"
+//	version      uint8    ContainerVersion
+//	type         uint8    ConstructionType
+//	count        uint32   number of sections
+//	index        [count]uint64, the length of each section in bytes
+//	sections     the sections, back to back
+//	checksum     [32]byte SHA-256 of everything above
+//
+// The checksum detects truncation and corruption. It is not a MAC--anyone can recompute it. Use Sign to detect tampering.
+func Seal(constrType ConstructionType, sections ...[]byte) []byte {
+	size := len(containerMagic) + 2 + 4 + 8*len(sections) + sha256.Size
+	for _, section := range sections {
+		size += len(section)
+	}
+
+	out := make([]byte, size)
+
+	base := copy(out, containerMagic)
+	out[base], out[base+1] = ContainerVersion, byte(constrType)
+	base += 2
+
+	binary.BigEndian.PutUint32(out[base:], uint32(len(sections)))
+	base += 4
+
+	for _, section := range sections {
+		binary.BigEndian.PutUint64(out[base:], uint64(len(section)))
+		base += 8
+	}
+
+	for _, section := range sections {
+		base += copy(out[base:], section)
+	}
+
+	checksum := sha256.Sum256(out[:base])
+	copy(out[base:], checksum[:])
+
+	return out
+}
+
+// HeaderVersion returns the version of the container format that in was written with. Unlike Header, it doesn't check
+// anything but the magic, so it works on containers of other versions.
+func HeaderVersion(in []byte) (uint8, error) {
+	if len(in) < len(containerMagic)+1 || !bytes.Equal(in[:len(containerMagic)], containerMagic) {
+		return 0, errors.New("not a white-box container")
+	}
+
+	return in[len(containerMagic)], nil
+}
+
+// Header reads the header of a container produced by Seal, without knowing what it holds. It returns the type of
+// construction in the container and the length of each section. It returns an error if the container is malformed,
+// corrupted, or of a different version.
+func Header(in []byte) (constrType ConstructionType, sizes []int, err error) {
+	header := len(containerMagic) + 2 + 4
+	if len(in) < header+sha256.Size {
+		return 0, nil, errors.New("container is too short")
+	}
+
+	body, checksum := in[:len(in)-sha256.Size], in[len(in)-sha256.Size:]
+	if real := sha256.Sum256(body); !bytes.Equal(real[:], checksum) {
+		return 0, nil, errors.New("container checksum mismatch")
+	} else if version, err := HeaderVersion(body); err != nil {
+		return 0, nil, err
+	} else if version != ContainerVersion {
+		return 0, nil, fmt.Errorf("unsupported container version %v", version)
+	}
+
+	constrType = ConstructionType(body[len(containerMagic)+1])
+
+	count := uint64(binary.BigEndian.Uint32(body[len(containerMagic)+2:]))
+	if uint64(len(body)) < uint64(header)+8*count {
+		return 0, nil, errors.New("container index is truncated")
+	}
+
+	index, rest := body[header:header+8*int(count)], uint64(len(body)-header-8*int(count))
+	sizes = make([]int, count)
+
+	for i := range sizes {
+		size := binary.BigEndian.Uint64(index[8*i:])
+		if size > rest {
+			return 0, nil, fmt.Errorf("section %v is truncated", i)
+		}
+
+		sizes[i], rest = int(size), rest-size
+	}
+
+	if rest != 0 {
+		return 0, nil, errors.New("container has trailing data")
+	}
+
+	return constrType, sizes, nil
+}
+
+// Open unpacks a container produced by Seal. It returns an error if the container is malformed, corrupted, of a
+// different version, or doesn't hold a construction of the given type. sizes is the expected length of each section; the
+// returned sections are sub-slices of in.
+func Open(constrType ConstructionType, in []byte, sizes ...int) (sections [][]byte, err error) {
+	ct, real, err := Header(in)
+	if err != nil {
+		return nil, err
+	} else if ct != constrType {
+		return nil, fmt.Errorf("container holds a %v construction, not %v", ct, constrType)
+	} else if len(real) != len(sizes) {
+		return nil, fmt.Errorf("container has %v sections, expected %v", len(real), len(sizes))
+	}
+
+	rest := in[len(containerMagic)+2+4+8*len(sizes):]
+	sections = make([][]byte, len(sizes))
+
+	for i, size := range sizes {
+		if real[i] != size {
+			return nil, fmt.Errorf("section %v has the wrong size", i)
+		}
+
+		sections[i], rest = rest[:size], rest[size:]
+	}
+
+	return sections, nil
+}
@@ -0,0 +1,48 @@
+package common
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestContainer(t *testing.T) {
+	a, b := []byte{1, 2, 3}, []byte{4, 5}
+
+	sealed := Seal(Chow, a, b)
+	sections, err := Open(Chow, sealed, 3, 2)
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	} else if !bytes.Equal(a, sections[0]) || !bytes.Equal(b, sections[1]) {
+		t.Fatalf("Sections disagree with originals! %x, %x != %x, %x", a, b, sections[0], sections[1])
+	}
+
+	constrType, sizes, err := Header(sealed)
+	if err != nil {
+		t.Fatalf("Header returned error: %v", err)
+	} else if constrType != Chow || len(sizes) != 2 || sizes[0] != 3 || sizes[1] != 2 {
+		t.Fatalf("Header disagrees with container! %v, %v", constrType, sizes)
+	}
+
+	if version, err := HeaderVersion(sealed); err != nil || version != ContainerVersion {
+		t.Fatalf("HeaderVersion disagrees with container! %v, %v", version, err)
+	} else if _, err := HeaderVersion([]byte("WBO")); err == nil {
+		t.Fatal("HeaderVersion accepted a truncated container!")
+	}
+
+	if _, err := Open(Xiao, sealed, 3, 2); err == nil {
+		t.Fatal("Open accepted the wrong construction type!")
+	}
+
+	if _, err := Open(Chow, sealed, 2, 3); err == nil {
+		t.Fatal("Open accepted sections of the wrong size!")
+	}
+
+	if _, err := Open(Chow, sealed[:len(sealed)-1], 3, 2); err == nil {
+		t.Fatal("Open accepted a truncated container!")
+	}
+
+	sealed[len(sealed)/2] ^= 0x01
+	if _, err := Open(Chow, sealed, 3, 2); err == nil {
+		t.Fatal("Open accepted a corrupted container!")
+	}
+}
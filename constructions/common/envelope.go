@@ -0,0 +1,119 @@
+package common
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// EnvelopeVersion is the version of the envelope format written by Marshal.
+const EnvelopeVersion = 1
+
+// Envelope is machine-readable metadata about a serialized construction, for tracking key packages across a fleet.
+// It refers to the serialized construction by its hash rather than embedding it.
+type Envelope struct {
+	Version int       `json:"version"`
+	Type    string    `json:"type"`    // The construction, as in ConstructionType.String.
+	Variant string    `json:"variant"` // The AES variant the construction computes. Always AES-128.
+	Options string    `json:"options"` // The key generation options, as in DescribeOpts.
+	Created time.Time `json:"created"`
+
+	// SeedHash is the hex-encoded SHA-256 hash of the seed the construction was generated with. It identifies the
+	// instance without revealing the seed.
+	SeedHash string `json:"seedHash"`
+
+	Payload Payload `json:"payload"`
+}
+
+// Payload refers to the serialized construction an envelope describes.
+type Payload struct {
+	URI    string `json:"uri,omitempty"` // Where the serialized construction is stored, if anywhere.
+	Size   int    `json:"size"`
+	SHA256 string `json:"sha256"` // Hex-encoded.
+}
+
+// NewEnvelope returns an envelope for the serialized construction payload, which was generated with the given seed and
+// options. opts may be nil for constructions that don't take options.
+func NewEnvelope(constrType ConstructionType, opts KeyGenerationOpts, seed, payload []byte) Envelope {
+	seedHash, payloadHash := sha256.Sum256(seed), sha256.Sum256(payload)
+
+	return Envelope{
+		Version:  EnvelopeVersion,
+		Type:     constrType.String(),
+		Variant:  "AES-128",
+		Options:  DescribeOpts(opts),
+		Created:  time.Now().UTC().Truncate(time.Second),
+		SeedHash: hex.EncodeToString(seedHash[:]),
+		Payload: Payload{
+			Size:   len(payload),
+			SHA256: hex.EncodeToString(payloadHash[:]),
+		},
+	}
+}
+
+// Marshal encodes the envelope as JSON. The encoding is deterministic--fields are always in the same order with no
+// extra whitespace--so it can be signed.
+func (e Envelope) Marshal() ([]byte, error) {
+	e.Created = e.Created.UTC()
+	return json.Marshal(e)
+}
+
+// ParseEnvelope decodes an envelope encoded by Marshal. It returns an error if the envelope is malformed or of a
+// different version.
+func ParseEnvelope(in []byte) (e Envelope, err error) {
+	if err = json.Unmarshal(in, &e); err != nil {
+		return
+	} else if e.Version != EnvelopeVersion {
+		return e, fmt.Errorf("unsupported envelope version %v", e.Version)
+	}
+
+	return
+}
+
+// Check returns an error if payload isn't the serialized construction the envelope describes.
+func (e Envelope) Check(payload []byte) error {
+	hash := sha256.Sum256(payload)
+
+	if len(payload) != e.Payload.Size {
+		return errors.New("payload has the wrong size")
+	} else if hex.EncodeToString(hash[:]) != e.Payload.SHA256 {
+		return errors.New("payload hash mismatch")
+	}
+
+	return nil
+}
+
+func (mt MaskType) String() string {
+	switch mt {
+	case RandomMask:
+		return "random"
+	case IdentityMask:
+		return "identity"
+	default:
+		return fmt.Sprintf("MaskType(%d)", int(mt))
+	}
+}
+
+// DescribeOpts returns a short, human-readable description of key generation options, like "independent(random,
+// identity)". It returns the empty string for nil.
+func DescribeOpts(opts KeyGenerationOpts) string {
+	switch opts := opts.(type) {
+	case nil:
+		return ""
+	case IndependentMasks:
+		return fmt.Sprintf("independent(%v,%v)", opts.Input, opts.Output)
+	case SameMasks:
+		return fmt.Sprintf("same(%v)", MaskType(opts))
+	case MatchingMasks:
+		return "matching"
+	case DeviceBinding:
+		return fmt.Sprintf("bound(%v)", DescribeOpts(opts.Masks))
+	case Watermark:
+		return fmt.Sprintf("watermarked(%v)", DescribeOpts(opts.Masks))
+	default:
+		return fmt.Sprintf("%T", opts)
+	}
+}
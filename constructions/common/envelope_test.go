@@ -0,0 +1,40 @@
+package common
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEnvelope(t *testing.T) {
+	seed, payload := []byte("seed"), Seal(Chow, []byte{1, 2, 3})
+
+	e1 := NewEnvelope(Chow, IndependentMasks{RandomMask, IdentityMask}, seed, payload)
+	if e1.Options != "independent(random,identity)" {
+		t.Fatalf("Options described wrong! %v", e1.Options)
+	}
+
+	m1, err := e1.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal returned error: %v", err)
+	}
+
+	e2, err := ParseEnvelope(m1)
+	if err != nil {
+		t.Fatalf("ParseEnvelope returned error: %v", err)
+	}
+
+	// Marshaling again must give the same bytes, so that signatures over it stay valid.
+	m2, _ := e2.Marshal()
+	if !bytes.Equal(m1, m2) {
+		t.Fatalf("Envelope changed after parsing! %s != %s", m1, m2)
+	}
+
+	if err := e2.Check(payload); err != nil {
+		t.Fatalf("Check returned error: %v", err)
+	}
+
+	payload[0] ^= 0x01
+	if err := e2.Check(payload); err == nil {
+		t.Fatal("Check accepted a modified payload!")
+	}
+}
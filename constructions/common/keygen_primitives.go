@@ -1,8 +1,15 @@
 package common
 
 import (
+	"crypto/sha256"
+	"io"
+
+	"github.com/OpenWhiteBox/primitives/encoding"
 	"github.com/OpenWhiteBox/primitives/matrix"
+	"github.com/OpenWhiteBox/primitives/number"
 	"github.com/OpenWhiteBox/primitives/random"
+
+	"github.com/OpenWhiteBox/AES/constructions/saes"
 )
 
 type Surface int
@@ -32,6 +39,22 @@ type SameMasks MaskType
 // MatchingMasks implies a randomly generated input mask and the inverse mask on the output.
 type MatchingMasks struct{}
 
+// DeviceBinding binds a construction to a device. The input and output masks are generated according to Masks, and
+// then composed with masks derived from Fingerprint, a caller-provided identifier of the device. See BindMasks.
+type DeviceBinding struct {
+	Masks       KeyGenerationOpts
+	Fingerprint []byte
+}
+
+// Watermark embeds a mark, like a customer ID, in a construction. The input and output masks are generated according to
+// Masks, exactly as without a watermark. Every internal encoding after them is drawn from a random source that depends
+// on Mark, returned by KeygenSource, so constructions with different marks compute the same function with different
+// tables.
+type Watermark struct {
+	Masks KeyGenerationOpts
+	Mark  []byte
+}
+
 // GenerateMasks generates input and output encodings for a white-box AES construction.
 func GenerateMasks(rs *random.Source, opts KeyGenerationOpts, inputMask, outputMask *matrix.Matrix) {
 	switch opts.(type) {
@@ -46,11 +69,82 @@ func GenerateMasks(rs *random.Source, opts KeyGenerationOpts, inputMask, outputM
 
 		*inputMask = mask
 		*outputMask, _ = mask.Invert()
+	case DeviceBinding:
+		binding := opts.(DeviceBinding)
+
+		GenerateMasks(rs, binding.Masks, inputMask, outputMask)
+		*inputMask, *outputMask = BindMasks(*inputMask, *outputMask, binding.Fingerprint)
+	case Watermark:
+		GenerateMasks(rs, opts.(Watermark).Masks, inputMask, outputMask)
 	default:
 		panic("Unrecognized key generation options!")
 	}
 }
 
+// KeygenSource returns the random source that the internal encodings of a construction should be drawn from, after
+// its masks are generated from rs with GenerateMasks. It's rs itself, unless opts include a Watermark, in which case
+// it's a new source derived from rs and the mark.
+func KeygenSource(rs *random.Source, opts KeyGenerationOpts) *random.Source {
+	switch opts.(type) {
+	case DeviceBinding:
+		return KeygenSource(rs, opts.(DeviceBinding).Masks)
+	case Watermark:
+		watermark := opts.(Watermark)
+
+		out := watermarkedSource(KeygenSource(rs, watermark.Masks), watermark.Mark)
+		return &out
+	default:
+		return rs
+	}
+}
+
+// BindMasks composes the input and output masks of a construction with the masks derived from a device fingerprint.
+//
+// Key generation with DeviceBinding returns bound masks. A key package is bound to a device by shipping it with the
+// unbound masks, from UnbindMasks, and having the device re-derive the bound masks at runtime from its own fingerprint.
+// On any other device, the derived masks are wrong and the construction computes garbage.
+func BindMasks(inputMask, outputMask matrix.Matrix, fingerprint []byte) (matrix.Matrix, matrix.Matrix) {
+	bindIn, bindOut := bindingMasks(fingerprint)
+	return inputMask.Compose(bindIn), bindOut.Compose(outputMask)
+}
+
+// UnbindMasks reverses BindMasks.
+func UnbindMasks(inputMask, outputMask matrix.Matrix, fingerprint []byte) (matrix.Matrix, matrix.Matrix) {
+	bindIn, bindOut := bindingMasks(fingerprint)
+	bindInInv, _ := bindIn.Invert()
+	bindOutInv, _ := bindOut.Invert()
+
+	return inputMask.Compose(bindInInv), bindOutInv.Compose(outputMask)
+}
+
+// bindingMasks derives the masks that bind a construction to the device with the given fingerprint.
+func bindingMasks(fingerprint []byte) (bindIn, bindOut matrix.Matrix) {
+	seed := sha256.Sum256(fingerprint)
+	rs := random.NewSource("Device Binding", seed[:16])
+
+	label := make([]byte, 16)
+
+	copy(label, []byte("BIND Inside"))
+	bindIn = rs.Matrix(label, 128)
+
+	copy(label, []byte("BIND Outside"))
+	bindOut = rs.Matrix(label, 128)
+
+	return
+}
+
+// watermarkedSource returns a new random source derived from rs and mark.
+func watermarkedSource(rs *random.Source, mark []byte) random.Source {
+	label := make([]byte, 16)
+	copy(label, []byte("WATERMARK"))
+
+	h := sha256.New()
+	io.CopyN(h, rs.Stream(label), 16)
+	h.Write(mark)
+
+	return random.NewSource("Watermarked Encodings", h.Sum(nil)[:16])
+}
+
 func generateMask(rs *random.Source, maskType MaskType, surface Surface) matrix.Matrix {
 	if maskType == RandomMask {
 		label := make([]byte, 16)
@@ -76,6 +170,74 @@ func MixingBijection(rs *random.Source, size, round, position int) matrix.Matrix
 	return rs.Matrix(label, size)
 }
 
+// sBox is AES' S-box as a Byte encoding.
+type sBox struct{}
+
+func (sb sBox) Encode(in byte) byte {
+	constr := saes.Construction{}
+	return constr.SubByte(in)
+}
+
+func (sb sBox) Decode(in byte) byte {
+	constr := saes.Construction{}
+	return constr.UnSubByte(in)
+}
+
+// frobenius is a Byte encoding that applies the Frobenius map x -> x^2 of GF(2^8) the given number of times.
+type frobenius int
+
+func (f frobenius) Encode(in byte) byte {
+	temp := number.ByteFieldElem(in)
+	for i := 0; i < int(f); i++ {
+		temp = temp.Mul(temp)
+	}
+
+	return byte(temp)
+}
+
+func (f frobenius) Decode(in byte) byte {
+	return frobenius((8 - int(f)) % 8).Encode(in)
+}
+
+// SBoxSelfEquivalence returns one of the 2040 affine self-equivalences of AES' S-box, such that
+// SubByte(in(x)) = out(SubByte(x)) for all x. Which one is chosen is derived from the random source, round, and
+// position.
+func SBoxSelfEquivalence(rs *random.Source, round, position int) (in, out encoding.ByteAffine) {
+	label := make([]byte, 16)
+	label[0], label[1], label[2], label[3] = 'S', 'E', byte(round), byte(position)
+	r := rs.Stream(label)
+
+	// Sample an index in [0, 2040) by rejection, so that every self-equivalence is equally likely.
+	buff, idx := make([]byte, 2), 0
+	for {
+		r.Read(buff)
+		idx = int(buff[0])<<8 | int(buff[1])
+
+		if idx < 2040*32 {
+			idx %= 2040
+			break
+		}
+	}
+
+	// Conjugating a self-equivalence of field inversion by the S-box gives a self-equivalence of the S-box, which is
+	// affine because the S-box's outer layer is.
+	inv, _ := InversionSelfEquivalence(number.ByteFieldElem(idx/8+1), idx%8)
+
+	in, _ = encoding.DecomposeByteAffine(inv)
+	out, _ = encoding.DecomposeByteAffine(encoding.ComposedBytes{encoding.InverseByte{sBox{}}, inv, sBox{}})
+
+	return
+}
+
+// InversionSelfEquivalence returns the self-equivalence x -> c * x^(2^k) of inversion in GF(2^8), such that
+// Invert(in(x)) = out(Invert(x)) for all x. c must be non-zero and k in [0, 8).
+func InversionSelfEquivalence(c number.ByteFieldElem, k int) (in, out encoding.Byte) {
+	in = encoding.ComposedBytes{frobenius(k), encoding.NewByteMultiplication(c)}
+	out = encoding.ComposedBytes{frobenius(k), encoding.NewByteMultiplication(c.Invert())}
+
+	return
+}
+
 type BlockMatrix struct {
 	Linear   matrix.Matrix
 	Constant [16]byte
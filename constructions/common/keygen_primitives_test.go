@@ -0,0 +1,43 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/OpenWhiteBox/primitives/number"
+	"github.com/OpenWhiteBox/primitives/random"
+)
+
+func TestSBoxSelfEquivalence(t *testing.T) {
+	rs := random.NewSource("Self-Equivalence Test", make([]byte, 16))
+	sbox := sBox{}
+
+	for pos := 0; pos < 16; pos++ {
+		in, out := SBoxSelfEquivalence(&rs, 0, pos)
+
+		for x := 0; x < 256; x++ {
+			real, cand := sbox.Encode(in.Encode(byte(x))), out.Encode(sbox.Encode(byte(x)))
+
+			if real != cand {
+				t.Fatalf("Self-equivalence %v disagrees at %x! %x != %x", pos, x, real, cand)
+			}
+		}
+	}
+}
+
+func TestInversionSelfEquivalence(t *testing.T) {
+	invert := func(x byte) byte { return byte(number.ByteFieldElem(x).Invert()) }
+
+	for _, c := range []number.ByteFieldElem{1, 2, 0x53, 0xff} {
+		for k := 0; k < 8; k++ {
+			in, out := InversionSelfEquivalence(c, k)
+
+			for x := 0; x < 256; x++ {
+				real, cand := invert(in.Encode(byte(x))), out.Encode(invert(byte(x)))
+
+				if real != cand {
+					t.Fatalf("Self-equivalence (%x, %v) disagrees at %x! %x != %x", c, k, x, real, cand)
+				}
+			}
+		}
+	}
+}
@@ -1,6 +1,9 @@
 package common
 
 import (
+	"runtime"
+	"sync"
+
 	"github.com/OpenWhiteBox/primitives/table"
 )
 
@@ -9,12 +12,36 @@ const (
 	SlicesSize = 65536 // = 16*SliceSize
 )
 
-func SerializeBlockMatrix(dst []byte, m [16]table.Block, xor BlockXORTables) int {
-	base := 0
+// SerializeTables writes the serializations of n tables, each size bytes long, to dst in order. serialize(i) returns the
+// serialization of the i-th table. Expanding tables is the slow part of serialization, so the tables are serialized
+// concurrently on every available core. It returns the number of bytes written.
+func SerializeTables(dst []byte, size, n int, serialize func(int) []byte) int {
+	var wg sync.WaitGroup
+	locs := make(chan int)
+
+	for worker := 0; worker < runtime.NumCPU(); worker++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for loc := range locs {
+				copy(dst[size*loc:size*(loc+1)], serialize(loc))
+			}
+		}()
+	}
 
-	for _, slice := range m {
-		base += copy(dst[base:], table.SerializeBlock(slice))
+	for loc := 0; loc < n; loc++ {
+		locs <- loc
 	}
+	close(locs)
+	wg.Wait()
+
+	return size * n
+}
+
+func SerializeBlockMatrix(dst []byte, m [16]table.Block, xor BlockXORTables) int {
+	base := SerializeTables(dst, SliceSize, 16, func(pos int) []byte { return table.SerializeBlock(m[pos]) })
 	base += copy(dst[base:], xor.Serialize())
 
 	return base
@@ -0,0 +1,22 @@
+package common
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSerializeTables(t *testing.T) {
+	real, cand := make([]byte, 0), make([]byte, 3*100)
+
+	for loc := 0; loc < 100; loc++ {
+		real = append(real, byte(loc), byte(loc), byte(loc))
+	}
+
+	n := SerializeTables(cand, 3, 100, func(loc int) []byte { return []byte{byte(loc), byte(loc), byte(loc)} })
+
+	if n != len(real) {
+		t.Fatalf("SerializeTables returned wrong length! %v != %v", n, len(real))
+	} else if !bytes.Equal(real, cand) {
+		t.Fatalf("SerializeTables wrote tables out of order! %x != %x", real, cand)
+	}
+}
@@ -0,0 +1,70 @@
+package common
+
+import (
+	"crypto/cipher"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Construction is the interface satisfied by every serializable white-box construction.
+type Construction interface {
+	cipher.Block
+	Serialize() []byte
+}
+
+// ParseFunc parses a serialized construction of one type. It's the Parse function of that construction's package.
+type ParseFunc func(in []byte) (Construction, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[ConstructionType]ParseFunc)
+)
+
+// Register makes a format available to Load. It's called from the init function of each construction's package, so
+// importing a construction is enough to be able to load it. Register panics if the type is registered twice.
+func Register(constrType ConstructionType, parse ParseFunc) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if parse == nil {
+		panic("common: Register parse function is nil")
+	} else if _, dup := registry[constrType]; dup {
+		panic(fmt.Sprintf("common: Register called twice for %v", constrType))
+	}
+
+	registry[constrType] = parse
+}
+
+// Registered returns the types of construction that can currently be loaded, in increasing order.
+func Registered() []ConstructionType {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	out := make([]ConstructionType, 0, len(registry))
+	for constrType := range registry {
+		out = append(out, constrType)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+
+	return out
+}
+
+// Load parses a serialized construction of any registered type. The type and format version are read from the
+// container's header. It returns an error if the container is malformed or holds a type that isn't registered.
+func Load(in []byte) (Construction, error) {
+	constrType, _, err := Header(in)
+	if err != nil {
+		return nil, err
+	}
+
+	registryMu.RLock()
+	parse, ok := registry[constrType]
+	registryMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("no format registered for %v constructions", constrType)
+	}
+
+	return parse(in)
+}
@@ -0,0 +1,31 @@
+package common
+
+import (
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	if _, err := Load(Seal(Full)); err == nil {
+		t.Fatal("Load accepted an unregistered construction type!")
+	}
+
+	Register(Full, func(in []byte) (Construction, error) { return nil, nil })
+	defer func() {
+		registryMu.Lock()
+		delete(registry, Full)
+		registryMu.Unlock()
+	}()
+
+	if _, err := Load(Seal(Full)); err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	} else if types := Registered(); len(types) != 1 || types[0] != Full {
+		t.Fatalf("Registered returned %v, expected [full]", types)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Register accepted a duplicate construction type!")
+		}
+	}()
+	Register(Full, func(in []byte) (Construction, error) { return nil, nil })
+}
@@ -0,0 +1,44 @@
+package common
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"errors"
+)
+
+// signatureMagic is the last four bytes of every signed serialization.
+var signatureMagic = []byte("WBSG")
+
+// Sign appends an Ed25519 signature over in to in. The layout is in, then the 64-byte signature, then "WBSG".
+//
+// The container's checksum only catches accidents; a signature also rejects key packages which were deliberately
+// modified, so that tampered tables fail to load instead of silently producing wrong ciphertexts. Sign works on any
+// byte slice, including flat key packages.
+func Sign(in []byte, key ed25519.PrivateKey) []byte {
+	out := make([]byte, 0, len(in)+ed25519.SignatureSize+len(signatureMagic))
+
+	out = append(out, in...)
+	out = append(out, ed25519.Sign(key, in)...)
+	out = append(out, signatureMagic...)
+
+	return out
+}
+
+// Verify checks the signature on a serialization produced by Sign, and returns the serialization without it. It returns
+// an error if there's no signature or it doesn't verify under key.
+func Verify(in []byte, key ed25519.PublicKey) ([]byte, error) {
+	trailer := ed25519.SignatureSize + len(signatureMagic)
+
+	if len(in) < trailer || !bytes.Equal(in[len(in)-len(signatureMagic):], signatureMagic) {
+		return nil, errors.New("serialization is not signed")
+	} else if len(key) != ed25519.PublicKeySize {
+		return nil, errors.New("public key has the wrong size")
+	}
+
+	body, signature := in[:len(in)-trailer], in[len(in)-trailer:len(in)-len(signatureMagic)]
+	if !ed25519.Verify(key, body, signature) {
+		return nil, errors.New("signature verification failed")
+	}
+
+	return body, nil
+}
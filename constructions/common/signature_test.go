@@ -0,0 +1,35 @@
+package common
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"testing"
+)
+
+func TestSignature(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(bytes.NewReader(make([]byte, ed25519.SeedSize)))
+	otherPub, _, _ := ed25519.GenerateKey(bytes.NewReader(bytes.Repeat([]byte{1}, ed25519.SeedSize)))
+
+	sealed := Seal(Chow, []byte{1, 2, 3})
+	signed := Sign(sealed, priv)
+
+	body, err := Verify(signed, pub)
+	if err != nil {
+		t.Fatalf("Verify returned error: %v", err)
+	} else if !bytes.Equal(sealed, body) {
+		t.Fatalf("Verified body disagrees with original! %x != %x", sealed, body)
+	}
+
+	if _, err := Verify(signed, otherPub); err == nil {
+		t.Fatal("Verify accepted the wrong public key!")
+	}
+
+	if _, err := Verify(sealed, pub); err == nil {
+		t.Fatal("Verify accepted an unsigned serialization!")
+	}
+
+	signed[len(signed)/4] ^= 0x01
+	if _, err := Verify(signed, pub); err == nil {
+		t.Fatal("Verify accepted a modified serialization!")
+	}
+}
@@ -0,0 +1,151 @@
+package common
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// wrapMagic is the first four bytes of every wrapped serialization.
+var wrapMagic = []byte("WBEN")
+
+const (
+	wrapVersion = 1
+
+	wrapKEK        = 1
+	wrapPassphrase = 2
+
+	// Argon2id parameters for passphrase wrapping: one pass over 64 MiB with four lanes.
+	argonTime, argonMemory, argonThreads = 1, 64 * 1024, 4
+
+	// argonMaxMemory bounds the memory a wrapped serialization can ask Argon2id for, in KiB, and argonMaxTime bounds the
+	// number of passes.
+	argonMaxMemory = 1024 * 1024
+	argonMaxTime   = 16
+)
+
+// WrapWithKEK encrypts a serialized construction (or any other byte slice) under the key-encryption key kek, which must
+// be a 16-, 24-, or 32-byte AES key, so that it isn't stored in the clear. The layout is "WBEN", a version byte, a mode
+// byte, a 12-byte nonce, and then the AES-GCM ciphertext. The header is authenticated.
+func WrapWithKEK(in, kek []byte) ([]byte, error) {
+	return wrap(in, kek, []byte{wrapKEK})
+}
+
+// UnwrapWithKEK decrypts a serialization wrapped by WrapWithKEK. It returns an error if kek is wrong or the wrapped
+// serialization was modified.
+func UnwrapWithKEK(in, kek []byte) ([]byte, error) {
+	header, rest, err := wrapHeader(in, wrapKEK)
+	if err != nil {
+		return nil, err
+	}
+
+	return unwrap(header, rest, kek)
+}
+
+// WrapWithPassphrase encrypts a serialized construction under a passphrase. The key is derived from the passphrase
+// with Argon2id and a random salt, which are stored after the mode byte along with the Argon2id parameters.
+func WrapWithPassphrase(in []byte, passphrase string) ([]byte, error) {
+	params := make([]byte, 1+16+4+4+1)
+	params[0] = wrapPassphrase
+
+	salt := params[1:17]
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	binary.BigEndian.PutUint32(params[17:], argonTime)
+	binary.BigEndian.PutUint32(params[21:], argonMemory)
+	params[25] = argonThreads
+
+	key := argon2.IDKey([]byte(passphrase), salt, argonTime, argonMemory, argonThreads, 32)
+
+	return wrap(in, key, params)
+}
+
+// UnwrapWithPassphrase decrypts a serialization wrapped by WrapWithPassphrase. It returns an error if the passphrase
+// is wrong or the wrapped serialization was modified.
+func UnwrapWithPassphrase(in []byte, passphrase string) ([]byte, error) {
+	header, rest, err := wrapHeader(in, wrapPassphrase)
+	if err != nil {
+		return nil, err
+	} else if len(rest) < 16+4+4+1 {
+		return nil, errors.New("wrapped serialization is too short")
+	}
+
+	salt := rest[:16]
+	time, memory, threads := binary.BigEndian.Uint32(rest[16:]), binary.BigEndian.Uint32(rest[20:]), rest[24]
+
+	if time == 0 || time > argonMaxTime || threads == 0 || memory > argonMaxMemory {
+		return nil, errors.New("wrapped serialization has bad key derivation parameters")
+	}
+
+	key := argon2.IDKey([]byte(passphrase), salt, time, memory, threads, 32)
+	header = in[:len(header)+16+4+4+1]
+
+	return unwrap(header, rest[25:], key)
+}
+
+// wrap encrypts in under key. The header--magic, version, and then params--is authenticated but not encrypted.
+func wrap(in, key, params []byte) ([]byte, error) {
+	aead, err := newWrapAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	header := append(append(append([]byte{}, wrapMagic...), wrapVersion), params...)
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(append(header, nonce...), nonce, in, header), nil
+}
+
+// wrapHeader checks the magic, version, and mode of a wrapped serialization. It returns the header up to and including
+// the mode byte, and everything after.
+func wrapHeader(in []byte, mode byte) (header, rest []byte, err error) {
+	size := len(wrapMagic) + 2
+
+	if len(in) < size {
+		return nil, nil, errors.New("wrapped serialization is too short")
+	} else if !bytes.Equal(in[:len(wrapMagic)], wrapMagic) {
+		return nil, nil, errors.New("not a wrapped serialization")
+	} else if in[len(wrapMagic)] != wrapVersion {
+		return nil, nil, errors.New("unsupported wrapped serialization version")
+	} else if in[len(wrapMagic)+1] != mode {
+		return nil, nil, errors.New("serialization is wrapped with a different kind of key")
+	}
+
+	return in[:size], in[size:], nil
+}
+
+// unwrap decrypts the nonce and ciphertext in rest under key, authenticating header.
+func unwrap(header, rest, key []byte) ([]byte, error) {
+	aead, err := newWrapAEAD(key)
+	if err != nil {
+		return nil, err
+	} else if len(rest) < aead.NonceSize() {
+		return nil, errors.New("wrapped serialization is too short")
+	}
+
+	out, err := aead.Open(nil, rest[:aead.NonceSize()], rest[aead.NonceSize():], header)
+	if err != nil {
+		return nil, errors.New("wrong key, or wrapped serialization was modified")
+	}
+
+	return out, nil
+}
+
+func newWrapAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,77 @@
+package common
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestWrapWithKEK(t *testing.T) {
+	kek, sealed := bytes.Repeat([]byte{7}, 16), Seal(Toy, []byte{1, 2, 3})
+
+	wrapped, err := WrapWithKEK(sealed, kek)
+	if err != nil {
+		t.Fatalf("WrapWithKEK returned error: %v", err)
+	} else if bytes.Contains(wrapped, sealed[:8]) {
+		t.Fatal("Wrapped serialization contains the plaintext!")
+	}
+
+	unwrapped, err := UnwrapWithKEK(wrapped, kek)
+	if err != nil {
+		t.Fatalf("UnwrapWithKEK returned error: %v", err)
+	} else if !bytes.Equal(sealed, unwrapped) {
+		t.Fatalf("Unwrapped disagrees with original! %x != %x", sealed, unwrapped)
+	}
+
+	if _, err := UnwrapWithKEK(wrapped, bytes.Repeat([]byte{8}, 16)); err == nil {
+		t.Fatal("UnwrapWithKEK accepted the wrong KEK!")
+	}
+
+	wrapped[len(wrapped)-1] ^= 0x01
+	if _, err := UnwrapWithKEK(wrapped, kek); err == nil {
+		t.Fatal("UnwrapWithKEK accepted a modified serialization!")
+	}
+}
+
+func TestWrapWithPassphrase(t *testing.T) {
+	sealed := Seal(Toy, []byte{1, 2, 3})
+
+	wrapped, err := WrapWithPassphrase(sealed, "correct horse")
+	if err != nil {
+		t.Fatalf("WrapWithPassphrase returned error: %v", err)
+	}
+
+	unwrapped, err := UnwrapWithPassphrase(wrapped, "correct horse")
+	if err != nil {
+		t.Fatalf("UnwrapWithPassphrase returned error: %v", err)
+	} else if !bytes.Equal(sealed, unwrapped) {
+		t.Fatalf("Unwrapped disagrees with original! %x != %x", sealed, unwrapped)
+	}
+
+	if _, err := UnwrapWithPassphrase(wrapped, "battery staple"); err == nil {
+		t.Fatal("UnwrapWithPassphrase accepted the wrong passphrase!")
+	}
+
+	// The salt is authenticated too.
+	wrapped[len(wrapMagic)+3] ^= 0x01
+	if _, err := UnwrapWithPassphrase(wrapped, "correct horse"); err == nil {
+		t.Fatal("UnwrapWithPassphrase accepted a modified salt!")
+	}
+
+	if _, err := UnwrapWithKEK(wrapped, bytes.Repeat([]byte{7}, 32)); err == nil {
+		t.Fatal("UnwrapWithKEK accepted a passphrase-wrapped serialization!")
+	}
+}
+
+func TestUnwrapWithPassphraseLimits(t *testing.T) {
+	wrapped, err := WrapWithPassphrase(Seal(Toy, []byte{1, 2, 3}), "correct horse")
+	if err != nil {
+		t.Fatalf("WrapWithPassphrase returned error: %v", err)
+	}
+
+	// Ask for 2^32-1 passes, which would hang if it were run.
+	binary.BigEndian.PutUint32(wrapped[len(wrapMagic)+2+16:], 0xffffffff)
+	if _, err := UnwrapWithPassphrase(wrapped, "correct horse"); err == nil {
+		t.Fatal("UnwrapWithPassphrase accepted an oversized time parameter!")
+	}
+}
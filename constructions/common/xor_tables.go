@@ -62,13 +62,11 @@ func (nxts NibbleXORTables) SquashBlocks(blocks [16][16]byte, dst []byte) {
 }
 
 func (nxts NibbleXORTables) Serialize() []byte {
-	dst, base := make([]byte, nxtsSize), 0
+	dst := make([]byte, nxtsSize)
 
-	for _, rack := range nxts {
-		for _, xorTable := range rack {
-			base += copy(dst[base:], table.SerializeNibble(xorTable))
-		}
-	}
+	SerializeTables(dst, nxtSize, 32*15, func(loc int) []byte {
+		return table.SerializeNibble(nxts[loc/15][loc%15])
+	})
 
 	return dst
 }
@@ -103,13 +101,11 @@ func (bxts ByteXORTables) SquashBlocks(blocks [16][16]byte, dst []byte) {
 }
 
 func (bxts ByteXORTables) Serialize() []byte {
-	dst, base := make([]byte, bxtsSize), 0
+	dst := make([]byte, bxtsSize)
 
-	for _, rack := range bxts {
-		for _, xorTable := range rack {
-			base += copy(dst[base:], table.SerializeDoubleToByte(xorTable))
-		}
-	}
+	SerializeTables(dst, bxtSize, 16*15, func(loc int) []byte {
+		return table.SerializeDoubleToByte(bxts[loc/15][loc%15])
+	})
 
 	return dst
 }
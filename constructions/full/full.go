@@ -5,6 +5,8 @@
 package full
 
 import (
+	"errors"
+
 	"github.com/OpenWhiteBox/primitives/encoding"
 	"github.com/OpenWhiteBox/primitives/matrix"
 )
@@ -97,3 +99,21 @@ func (constr Construction) Encrypt(dst, src []byte) {
 
 // Decrypt is not implemented.
 func (constr Construction) Decrypt(_, _ []byte) {}
+
+// Reencode composes additional linear external encodings onto the construction, without knowledge of its key or masks.
+// The returned construction computes b(constr(a(x))): if constr has masks inputMask and outputMask, the returned
+// construction's input mask encodes with a and then inputMask, and its output mask encodes with outputMask and then b.
+// a and b must be invertible 128x128 matrices.
+func (constr *Construction) Reencode(a, b matrix.Matrix) (Construction, error) {
+	if _, ok := a.Invert(); !ok {
+		return Construction{}, errors.New("input encoding isn't invertible")
+	} else if _, ok := b.Invert(); !ok {
+		return Construction{}, errors.New("output encoding isn't invertible")
+	}
+
+	out := *constr
+	out[0] = constr[0].compose(&blockAffine{linear: a, constant: matrix.Row(make([]byte, 16))})
+	out[40] = (&blockAffine{linear: b, constant: matrix.Row(make([]byte, 16))}).compose(constr[40])
+
+	return out, nil
+}
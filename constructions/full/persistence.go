@@ -1,29 +1,94 @@
 package full
 
 import (
-	"errors"
+	"crypto/ed25519"
+
+	"github.com/OpenWhiteBox/AES/constructions/common"
+	"github.com/OpenWhiteBox/AES/flat"
 )
 
+// sectionSizes returns the size of each serialized affine layer, in order.
+func sectionSizes() []int {
+	sizes := make([]int, 41)
+
+	for i := range sizes {
+		inSize, outSize := 16, 16
+
+		if i > 0 {
+			inSize = stateSize[(i-1)%4]
+		}
+		if i < 40 {
+			outSize = stateSize[i%4] + compressSize[i%4]
+		}
+
+		// Two bytes of dimensions, then one row per output bit, then the constant.
+		sizes[i] = 2 + 8*outSize*inSize + outSize
+	}
+
+	return sizes
+}
+
+func init() {
+	common.Register(common.Full, func(in []byte) (common.Construction, error) {
+		constr, err := Parse(in)
+		if err != nil {
+			return nil, err
+		}
+
+		return &constr, nil
+	})
+}
+
 // Serialize serializes a white-box construction into a byte slice.
 func (constr *Construction) Serialize() []byte {
-	out := make([]byte, 0)
+	sections := make([][]byte, len(constr))
 
-	for _, round := range constr {
-		round.serialize(&out)
+	for i, round := range constr {
+		round.serialize(&sections[i])
 	}
 
-	return out
+	return common.Seal(common.Full, sections...)
 }
 
-// Parse parses a byte array into a white-box construction. It returns an error if the byte slice isn't long enough.
+// Flatten serializes the construction into the flat key-package format of the flat package. Each layer is laid out
+// the same way as in Serialize.
+func (constr *Construction) Flatten() []byte {
+	tables := make([][]byte, len(constr))
+
+	for i, round := range constr {
+		round.serialize(&tables[i])
+	}
+
+	return flat.Build(flat.Full, tables...)
+}
+
+// Parse parses a byte array into a white-box construction. It returns an error if the byte slice isn't a valid,
+// uncorrupted serialization of a full construction.
 func Parse(in []byte) (constr Construction, err error) {
-	if len(in) != 1091178 {
-		return constr, errors.New("key is the wrong size")
+	sections, err := common.Open(common.Full, in, sectionSizes()...)
+	if err != nil {
+		return
 	}
 
 	for i := 0; i < len(constr); i++ {
-		constr[i], in = parseBlockAffine(in)
+		constr[i], _ = parseBlockAffine(sections[i])
 	}
 
 	return
 }
+
+// SerializeSigned serializes a white-box construction into a byte slice, and signs it with key.
+func (constr *Construction) SerializeSigned(key ed25519.PrivateKey) []byte {
+	return common.Sign(constr.Serialize(), key)
+}
+
+// ParseSigned parses a signed byte slice into a white-box construction. It returns an error if the signature doesn't
+// verify under key, or if the byte slice isn't a valid, uncorrupted serialization of a full construction.
+func ParseSigned(in []byte, key ed25519.PublicKey) (constr Construction, err error) {
+	body, err := common.Verify(in, key)
+	if err != nil {
+		return
+	}
+
+	return Parse(body)
+}
@@ -49,27 +49,6 @@ func (p *permutation) Decode(in [16]byte) (out [16]byte) {
 	return out
 }
 
-type frobenius int
-
-func (f frobenius) Encode(in byte) (out byte) {
-	temp := number.ByteFieldElem(in)
-	for i := 0; i < int(f); i++ {
-		temp = temp.Mul(temp)
-	}
-
-	return byte(temp)
-}
-
-func (f frobenius) Decode(in byte) (out byte) {
-	temp := number.ByteFieldElem(in)
-	g := (8 - int(f)) % 8
-	for i := 0; i < g; i++ {
-		temp = temp.Mul(temp)
-	}
-
-	return byte(temp)
-}
-
 // generateSelfEquivalence returns a random self-equivalence of the S-box layer, so that \zeta(x) = bInv(\zeta(a(x))).
 func generateSelfEquivalence(r io.Reader) (a, bInv encoding.Block) {
 	// Sample a byte-wise permutation to apply to the input.
@@ -77,28 +56,27 @@ func generateSelfEquivalence(r io.Reader) (a, bInv encoding.Block) {
 
 	// Sample one non-zero scalar for each byte. Each byte of the input is multiplied by this scalar.
 	buff := make([]byte, 1)
-	scalars := encoding.ConcatenatedBlock{}
+	scalars := [16]number.ByteFieldElem{}
 
 	for pos := 0; pos < 16; {
 		r.Read(buff)
 		if buff[0] != 0x00 {
-			scalars[pos] = encoding.NewByteMultiplication(number.ByteFieldElem(buff[0]))
+			scalars[pos] = number.ByteFieldElem(buff[0])
 			pos++
 		}
 	}
 
-	// Sample a random value in [0, 8) for each byte. This is the number of times to apply the Frobenius.
-	frobs := encoding.ConcatenatedBlock{}
+	// Sample a random value in [0, 8) for each byte. This is the number of times to apply the Frobenius. Together with
+	// the scalar, it gives a self-equivalence of inversion on each byte.
+	in, outInv := encoding.ConcatenatedBlock{}, encoding.ConcatenatedBlock{}
 	for pos := 0; pos < 16; pos++ {
 		r.Read(buff)
-		frobs[pos] = frobenius(buff[0] & 0x7)
+
+		inByte, outByte := common.InversionSelfEquivalence(scalars[pos], int(buff[0]&0x7))
+		in[pos], outInv[pos] = inByte, encoding.InverseByte{outByte}
 	}
 
-	return encoding.ComposedBlocks{
-			frobs, scalars, p,
-		}, encoding.ComposedBlocks{
-			encoding.InverseBlock{p}, scalars, encoding.InverseBlock{frobs},
-		}
+	return encoding.ComposedBlocks{in, p}, encoding.ComposedBlocks{encoding.InverseBlock{p}, outInv}
 }
 
 // shiftRoundKey adds the fixed SubBytes constant to a round key and returns the result as an encoding.Block.
@@ -1,36 +1,73 @@
 package toy
 
 import (
-	"errors"
+	"crypto/ed25519"
 
 	"github.com/OpenWhiteBox/primitives/encoding"
 	"github.com/OpenWhiteBox/primitives/matrix"
+
+	"github.com/OpenWhiteBox/AES/constructions/common"
+	"github.com/OpenWhiteBox/AES/flat"
 )
 
-const fullSize = 11 * (128 + 1) * 16
+// roundSize is the size of one serialized affine layer: a 128x128 matrix and a 16-byte constant.
+const roundSize = (128 + 1) * 16
+
+func init() {
+	common.Register(common.Toy, func(in []byte) (common.Construction, error) {
+		constr, err := Parse(in)
+		if err != nil {
+			return nil, err
+		}
+
+		return &constr, nil
+	})
+}
 
 // Serialize serializes a white-box construction into a byte slice.
 func (constr *Construction) Serialize() []byte {
-	out := make([]byte, 0)
+	sections := make([][]byte, 11)
+
+	for i, round := range constr {
+		out := make([]byte, 0, roundSize)
 
-	for _, round := range constr {
 		for _, row := range round.Forwards {
 			out = append(out, row...)
 		}
-		out = append(out, round.BlockAdditive[:]...)
+		sections[i] = append(out, round.BlockAdditive[:]...)
+	}
+
+	return common.Seal(common.Toy, sections...)
+}
+
+// Flatten serializes the construction into the flat key-package format of the flat package.
+func (constr *Construction) Flatten() []byte {
+	linear, constant := make([]byte, 0, 11*128*16), make([]byte, 0, 11*16)
+
+	for _, round := range constr {
+		for _, row := range round.Forwards {
+			linear = append(linear, row...)
+		}
+		constant = append(constant, round.BlockAdditive[:]...)
 	}
 
-	return out
+	return flat.Build(flat.Toy, linear, constant)
 }
 
-// Parse parses a byte array into a white-box construction. It returns an error if the byte slice isn't long enough.
+// Parse parses a byte array into a white-box construction. It returns an error if the byte slice isn't a valid,
+// uncorrupted serialization of a toy construction.
 func Parse(in []byte) (constr Construction, err error) {
-	if len(in) != fullSize {
-		err = errors.New("Parsing the key failed.")
+	sizes := make([]int, 11)
+	for i := range sizes {
+		sizes[i] = roundSize
+	}
+
+	sections, err := common.Open(common.Toy, in, sizes...)
+	if err != nil {
 		return
 	}
 
-	for round := 0; round < 11; round++ {
+	for round, in := range sections {
 		forwards := matrix.Matrix{}
 		constant := [16]byte{}
 
@@ -39,10 +76,25 @@ func Parse(in []byte) (constr Construction, err error) {
 			in = in[16:]
 		}
 		copy(constant[:], in[:16])
-		in = in[16:]
 
 		constr[round] = encoding.NewBlockAffine(forwards, constant)
 	}
 
 	return
 }
+
+// SerializeSigned serializes a white-box construction into a byte slice, and signs it with key.
+func (constr *Construction) SerializeSigned(key ed25519.PrivateKey) []byte {
+	return common.Sign(constr.Serialize(), key)
+}
+
+// ParseSigned parses a signed byte slice into a white-box construction. It returns an error if the signature doesn't
+// verify under key, or if the byte slice isn't a valid, uncorrupted serialization of a toy construction.
+func ParseSigned(in []byte, key ed25519.PublicKey) (constr Construction, err error) {
+	body, err := common.Verify(in, key)
+	if err != nil {
+		return
+	}
+
+	return Parse(body)
+}
@@ -4,7 +4,10 @@
 package toy
 
 import (
+	"errors"
+
 	"github.com/OpenWhiteBox/primitives/encoding"
+	"github.com/OpenWhiteBox/primitives/matrix"
 	"github.com/OpenWhiteBox/primitives/number"
 )
 
@@ -31,6 +34,12 @@ func (constr Construction) Encrypt(dst, src []byte) {
 	copy(dst[:], state[:])
 }
 
+// Invertible marks the construction as computing both directions of AES under the same masks. Decrypt takes a block
+// under the output mask and returns one under the input mask--the reverse of Encrypt--so to decrypt with standard AES,
+// encode the ciphertext with the output mask first and decode the result with the input mask. It does nothing when
+// called. (Necessary to implement modes.Invertible.)
+func (constr Construction) Invertible() {}
+
 // Decrypt decrypts the first block in src into dst. Dst and src may point at the same memory.
 func (constr Construction) Decrypt(dst, src []byte) {
 	state := [16]byte{}
@@ -48,3 +57,21 @@ func (constr Construction) Decrypt(dst, src []byte) {
 
 	copy(dst[:], state[:])
 }
+
+// Reencode composes additional linear external encodings onto the construction, without knowledge of its key or masks.
+// The returned construction computes b(constr(a(x))): if constr has masks inputMask and outputMask, the returned
+// construction's input mask encodes with a and then inputMask, and its output mask encodes with outputMask and then b.
+// a and b must be invertible 128x128 matrices.
+func (constr *Construction) Reencode(a, b matrix.Matrix) (Construction, error) {
+	if _, ok := a.Invert(); !ok {
+		return Construction{}, errors.New("input encoding isn't invertible")
+	} else if _, ok := b.Invert(); !ok {
+		return Construction{}, errors.New("output encoding isn't invertible")
+	}
+
+	out := *constr
+	out[0], _ = encoding.DecomposeBlockAffine(encoding.ComposedBlocks{encoding.NewBlockLinear(a), constr[0]})
+	out[10], _ = encoding.DecomposeBlockAffine(encoding.ComposedBlocks{constr[10], encoding.NewBlockLinear(b)})
+
+	return out, nil
+}
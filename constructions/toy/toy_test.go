@@ -2,8 +2,13 @@ package toy
 
 import (
 	"bytes"
+	"crypto/aes"
+	"crypto/ed25519"
 	"testing"
 
+	"github.com/OpenWhiteBox/primitives/encoding"
+
+	"github.com/OpenWhiteBox/AES/constructions/common"
 	test_vectors "github.com/OpenWhiteBox/AES/constructions/test"
 )
 
@@ -70,3 +75,84 @@ func TestPersistence(t *testing.T) {
 		t.Fatalf("Real disagrees with parsed! %x != %x", cand1, cand2)
 	}
 }
+
+func TestReencode(t *testing.T) {
+	constr1, inputMask, outputMask := GenerateKeys(key, seed)
+	_, a, b := GenerateKeys(key, key)
+
+	constr2, err := constr1.Reencode(a.Forwards, b.Forwards)
+	if err != nil {
+		t.Fatalf("Reencode returned error: %v", err)
+	}
+
+	in, out := [16]byte{}, [16]byte{}
+	copy(in[:], input)
+
+	in = inputMask.Decode(in)
+	in = encoding.NewBlockLinear(a.Forwards).Decode(in) // Apply input encoding.
+
+	constr2.Encrypt(out[:], in[:])
+
+	out = encoding.NewBlockLinear(b.Forwards).Decode(out)
+	out = outputMask.Decode(out) // Remove output encoding.
+
+	real := make([]byte, 16)
+	c, _ := aes.NewCipher(key)
+	c.Encrypt(real, input)
+
+	if !bytes.Equal(real, out[:]) {
+		t.Fatalf("Real disagrees with result! %x != %x", real, out)
+	}
+}
+
+func TestSignedPersistence(t *testing.T) {
+	priv := ed25519.NewKeyFromSeed(bytes.Repeat(seed, 2))
+	pub := priv.Public().(ed25519.PublicKey)
+
+	constr1, _, _ := GenerateKeys(key, seed)
+
+	signed := constr1.SerializeSigned(priv)
+	constr2, err := ParseSigned(signed, pub)
+
+	if err != nil {
+		t.Fatalf("ParseSigned returned error: %v", err)
+	}
+
+	cand1, cand2 := make([]byte, 16), make([]byte, 16)
+
+	constr1.Encrypt(cand1, input)
+	constr2.Encrypt(cand2, input)
+
+	if !bytes.Equal(cand1, cand2) {
+		t.Fatalf("Real disagrees with parsed! %x != %x", cand1, cand2)
+	}
+
+	// Flip a bit in the tables.
+	signed[100] ^= 0x01
+	if _, err := ParseSigned(signed, pub); err == nil {
+		t.Fatal("ParseSigned accepted a modified construction!")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	constr1, _, _ := GenerateKeys(key, seed)
+
+	constr2, err := common.Load(constr1.Serialize())
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+
+	cand1, cand2 := make([]byte, 16), make([]byte, 16)
+
+	constr1.Encrypt(cand1, input)
+	constr2.Encrypt(cand2, input)
+
+	if !bytes.Equal(cand1, cand2) {
+		t.Fatalf("Real disagrees with loaded! %x != %x", cand1, cand2)
+	}
+
+	// A container with the right type but a malformed construction gives an error and no construction.
+	if constr, err := common.Load(common.Seal(common.Toy, []byte{1})); err == nil || constr != nil {
+		t.Fatalf("Load returned %v, %v for a malformed construction!", constr, err)
+	}
+}
@@ -62,7 +62,8 @@ func generateBarriers(rs *random.Source, out *Construction, inputMask, outputMas
 }
 
 // GenerateEncryptionKeys creates a white-boxed version of the AES key `key` for encryption, with any non-determinism
-// generated by `seed`.
+// generated by `seed`. Opts specifies the input and output masks, as in chow: common.{IndependentMasks, SameMasks,
+// MatchingMasks}, or a common.DeviceBinding or common.Watermark wrapping one of them.
 func GenerateEncryptionKeys(key, seed []byte, opts common.KeyGenerationOpts) (out Construction, inputMask, outputMask matrix.Matrix) {
 	rs := random.NewSource("Xiao Encryption", seed)
 
@@ -96,14 +97,17 @@ func GenerateEncryptionKeys(key, seed []byte, opts common.KeyGenerationOpts) (ou
 	}
 
 	common.GenerateMasks(&rs, opts, &inputMask, &outputMask)
-	generateRoundMaterial(&rs, &out, hidden)
-	generateBarriers(&rs, &out, &inputMask, &outputMask, &shiftRows)
+	ers := common.KeygenSource(&rs, opts)
+
+	generateRoundMaterial(ers, &out, hidden)
+	generateBarriers(ers, &out, &inputMask, &outputMask, &shiftRows)
 
 	return out, inputMask, outputMask
 }
 
 // GenerateDecryptionKeys creates a white-boxed version of the AES key `key` for decryption, with any non-determinism
-// generated by `seed`.
+// generated by `seed`. Opts specifies the input and output masks, as in chow: common.{IndependentMasks, SameMasks,
+// MatchingMasks}, or a common.DeviceBinding or common.Watermark wrapping one of them.
 func GenerateDecryptionKeys(key, seed []byte, opts common.KeyGenerationOpts) (out Construction, inputMask, outputMask matrix.Matrix) {
 	rs := random.NewSource("Xiao Decryption", seed)
 
@@ -144,8 +148,10 @@ func GenerateDecryptionKeys(key, seed []byte, opts common.KeyGenerationOpts) (ou
 	}
 
 	common.GenerateMasks(&rs, opts, &inputMask, &outputMask)
-	generateRoundMaterial(&rs, &out, hidden)
-	generateBarriers(&rs, &out, &inputMask, &outputMask, &unShiftRows)
+	ers := common.KeygenSource(&rs, opts)
+
+	generateRoundMaterial(ers, &out, hidden)
+	generateBarriers(ers, &out, &inputMask, &outputMask, &unShiftRows)
 
 	return out, inputMask, outputMask
 }
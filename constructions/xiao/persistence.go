@@ -1,48 +1,79 @@
 package xiao
 
 import (
+	"crypto/ed25519"
 	"errors"
 
 	"github.com/OpenWhiteBox/primitives/matrix"
 	"github.com/OpenWhiteBox/primitives/table"
+
+	"github.com/OpenWhiteBox/AES/constructions/common"
+	"github.com/OpenWhiteBox/AES/flat"
 )
 
 const (
-	fullSize = 20994048
-
 	matrixSize = 16 * 128
 	tmcSize    = 65536 * 4
 )
 
+// sectionSizes is the size of each section of a serialized construction: FinalMask, ShiftRows, and TBoxMixCol.
+var sectionSizes = []int{matrixSize, 10 * matrixSize, 10 * 8 * tmcSize}
+
+func init() {
+	common.Register(common.Xiao, func(in []byte) (common.Construction, error) {
+		constr, err := Parse(in)
+		if err != nil {
+			return nil, err
+		}
+
+		return &constr, nil
+	})
+
+	common.Register(common.XiaoPiece, func(in []byte) (common.Construction, error) {
+		p, err := ParsePiece(in)
+		if err != nil {
+			return nil, err
+		}
+
+		return &p, nil
+	})
+}
+
 // Serialize serializes a white-box construction into a byte slice.
 func (constr *Construction) Serialize() []byte {
-	out, base := make([]byte, fullSize), 0
+	finalMask, shiftRows := make([]byte, sectionSizes[0]), make([]byte, sectionSizes[1])
+	tboxMixCol := make([]byte, sectionSizes[2])
 
-	base += serializeMatrix(out[base:], constr.FinalMask)
+	serializeMatrix(finalMask, constr.FinalMask)
 
+	base := 0
 	for _, sr := range constr.ShiftRows {
-		base += serializeMatrix(out[base:], sr)
+		base += serializeMatrix(shiftRows[base:], sr)
 	}
 
-	for _, round := range constr.TBoxMixCol {
-		for _, tmc := range round {
-			base += copy(out[base:], table.SerializeDoubleToWord(tmc))
-		}
-	}
+	common.SerializeTables(tboxMixCol, tmcSize, 10*8, func(loc int) []byte {
+		return table.SerializeDoubleToWord(constr.TBoxMixCol[loc/8][loc%8])
+	})
 
-	return out
+	return common.Seal(common.Xiao, finalMask, shiftRows, tboxMixCol)
 }
 
-// Parse parses a byte array into a white-box construction. It returns an error if the byte array isn't long enough.
+// Parse parses a byte array into a white-box construction. It returns an error if the byte array isn't a valid,
+// uncorrupted serialization of a Xiao-Lai construction.
 func Parse(in []byte) (constr Construction, err error) {
-	var rest []byte
+	sections, err := common.Open(common.Xiao, in, sectionSizes...)
+	if err != nil {
+		return
+	}
 
-	constr.FinalMask, rest = parseMatrix(in)
+	constr.FinalMask, _ = parseMatrix(sections[0])
 
+	rest := sections[1]
 	for i, _ := range constr.ShiftRows {
 		constr.ShiftRows[i], rest = parseMatrix(rest)
 	}
 
+	rest = sections[2]
 	for i, _ := range constr.TBoxMixCol {
 		for j, _ := range constr.TBoxMixCol[i] {
 			constr.TBoxMixCol[i][j] = table.ParsedDoubleToWord(rest[:tmcSize])
@@ -50,11 +81,109 @@ func Parse(in []byte) (constr Construction, err error) {
 		}
 	}
 
-	if rest == nil {
-		err = errors.New("Parsing the key failed!")
+	return
+}
+
+// Serialize serializes a piece of a split construction into a byte slice. The layout is the same as a whole
+// construction's, with as many ShiftRows matrices and rounds of TMC tables as the piece has rounds.
+func (p *Piece) Serialize() []byte {
+	finalMask, shiftRows := make([]byte, matrixSize), make([]byte, len(p.ShiftRows)*matrixSize)
+	tboxMixCol := make([]byte, len(p.TBoxMixCol)*8*tmcSize)
+
+	serializeMatrix(finalMask, p.FinalMask)
+
+	base := 0
+	for _, sr := range p.ShiftRows {
+		base += serializeMatrix(shiftRows[base:], sr)
 	}
 
-	return
+	common.SerializeTables(tboxMixCol, tmcSize, len(p.TBoxMixCol)*8, func(loc int) []byte {
+		return table.SerializeDoubleToWord(p.TBoxMixCol[loc/8][loc%8])
+	})
+
+	return common.Seal(common.XiaoPiece, finalMask, shiftRows, tboxMixCol)
+}
+
+// ParsePiece parses a byte array into a piece of a split construction. It returns an error if the byte array isn't a
+// valid, uncorrupted serialization of a piece.
+func ParsePiece(in []byte) (p Piece, err error) {
+	_, sizes, err := common.Header(in)
+	if err != nil {
+		return
+	} else if len(sizes) != 3 || sizes[1]%matrixSize != 0 {
+		return p, errors.New("malformed piece")
+	}
+
+	rounds := sizes[1] / matrixSize
+	if rounds < 1 || rounds > 9 {
+		return p, errors.New("piece must have between 1 and 9 rounds")
+	}
+
+	sections, err := common.Open(common.XiaoPiece, in, matrixSize, rounds*matrixSize, rounds*8*tmcSize)
+	if err != nil {
+		return
+	}
+
+	p.FinalMask, _ = parseMatrix(sections[0])
+
+	p.ShiftRows = make([]matrix.Matrix, rounds)
+	rest := sections[1]
+	for i := range p.ShiftRows {
+		p.ShiftRows[i], rest = parseMatrix(rest)
+	}
+
+	p.TBoxMixCol = make([][8]table.DoubleToWord, rounds)
+	rest = sections[2]
+	for i := range p.TBoxMixCol {
+		for j := range p.TBoxMixCol[i] {
+			p.TBoxMixCol[i][j] = table.ParsedDoubleToWord(rest[:tmcSize])
+			rest = rest[tmcSize:]
+		}
+	}
+
+	return p, nil
+}
+
+// SerializeSigned serializes a white-box construction into a byte slice, and signs it with key.
+func (constr *Construction) SerializeSigned(key ed25519.PrivateKey) []byte {
+	return common.Sign(constr.Serialize(), key)
+}
+
+// ParseSigned parses a signed byte slice into a white-box construction. It returns an error if the signature doesn't
+// verify under key, or if the byte slice isn't a valid, uncorrupted serialization of a Xiao-Lai construction.
+func ParseSigned(in []byte, key ed25519.PublicKey) (constr Construction, err error) {
+	body, err := common.Verify(in, key)
+	if err != nil {
+		return
+	}
+
+	return Parse(body)
+}
+
+// Flatten serializes the construction into the flat key-package format of the flat package.
+func (constr *Construction) Flatten() []byte {
+	shiftRows, finalMask := make([]byte, sectionSizes[1]), make([]byte, sectionSizes[0])
+	tboxMixCol := make([]byte, sectionSizes[2])
+
+	base := 0
+	for _, sr := range constr.ShiftRows {
+		base += serializeMatrix(shiftRows[base:], sr)
+	}
+
+	// Tables are expanded in input order, rather than however the table package serializes them.
+	common.SerializeTables(tboxMixCol, tmcSize, 10*8, func(loc int) []byte {
+		out := make([]byte, 0, tmcSize)
+		for i := 0; i < 65536; i++ {
+			word := constr.TBoxMixCol[loc/8][loc%8].Get([2]byte{byte(i >> 8), byte(i)})
+			out = append(out, word[:]...)
+		}
+
+		return out
+	})
+
+	serializeMatrix(finalMask, constr.FinalMask)
+
+	return flat.Build(flat.Xiao, shiftRows, tboxMixCol, finalMask)
 }
 
 func serializeMatrix(dst []byte, m matrix.Matrix) int {
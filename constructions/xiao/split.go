@@ -0,0 +1,87 @@
+package xiao
+
+import (
+	"errors"
+
+	"github.com/OpenWhiteBox/primitives/matrix"
+	"github.com/OpenWhiteBox/primitives/random"
+	"github.com/OpenWhiteBox/primitives/table"
+)
+
+// Piece is a run of consecutive rounds of a Xiao-Lai construction, produced by Split. Each round is a ShiftRows and
+// re-encoding matrix followed by the round's TMC tables, and the last round is followed by FinalMask.
+type Piece struct {
+	ShiftRows  []matrix.Matrix
+	TBoxMixCol [][8]table.DoubleToWord
+
+	FinalMask matrix.Matrix
+}
+
+// BlockSize returns the block size of AES. (Necessary to implement cipher.Block.)
+func (p Piece) BlockSize() int { return 16 }
+
+// Encrypt pushes the first block in src through the piece's rounds and writes the result to dst. Dst and src may point
+// at the same memory.
+func (p Piece) Encrypt(dst, src []byte) {
+	p.crypt(dst, src)
+}
+
+// Decrypt is the same as Encrypt: which direction a piece computes depends on the construction it was split from.
+func (p Piece) Decrypt(dst, src []byte) {
+	p.crypt(dst, src)
+}
+
+func (p *Piece) crypt(dst, src []byte) {
+	copy(dst, src)
+
+	for round := range p.ShiftRows {
+		copy(dst, p.ShiftRows[round].Mul(matrix.Row(dst)))
+
+		for pos := 0; pos < 16; pos += 4 {
+			left := p.TBoxMixCol[round][pos/2].Get([2]byte{dst[pos], dst[pos+1]})
+			right := p.TBoxMixCol[round][pos/2+1].Get([2]byte{dst[pos+2], dst[pos+3]})
+
+			for i := 0; i < 4; i++ {
+				dst[pos+i] = left[i] ^ right[i]
+			}
+		}
+	}
+
+	copy(dst, p.FinalMask.Mul(matrix.Row(dst)))
+}
+
+// Split partitions the rounds of the construction in two at round, which must be between 1 and 9. The client piece
+// computes the rounds before round, and the server piece computes round and the rest, so that server(client(x)) is
+// constr(x). Neither piece alone computes the cipher.
+//
+// The state at the cut is encoded with a random invertible matrix generated from seed: the client piece ends with it,
+// and the server piece's first ShiftRows matrix starts with its inverse. So the intermediate value the client sends to
+// the server is in a fresh encoding, and pieces from splits with different seeds don't fit together.
+func (constr *Construction) Split(round int, seed []byte) (client, server Piece, err error) {
+	if round < 1 || round > 9 {
+		return Piece{}, Piece{}, errors.New("round must be between 1 and 9")
+	}
+
+	rs := random.NewSource("Xiao Split", seed)
+
+	label := make([]byte, 16)
+	copy(label, []byte("Cut"))
+
+	cut := rs.Matrix(label, 128)
+	cutInv, _ := cut.Invert()
+
+	client = Piece{
+		ShiftRows:  append([]matrix.Matrix{}, constr.ShiftRows[:round]...),
+		TBoxMixCol: append([][8]table.DoubleToWord{}, constr.TBoxMixCol[:round]...),
+		FinalMask:  cut,
+	}
+
+	server = Piece{
+		ShiftRows:  append([]matrix.Matrix{}, constr.ShiftRows[round:]...),
+		TBoxMixCol: append([][8]table.DoubleToWord{}, constr.TBoxMixCol[round:]...),
+		FinalMask:  constr.FinalMask,
+	}
+	server.ShiftRows[0] = server.ShiftRows[0].Compose(cutInv)
+
+	return client, server, nil
+}
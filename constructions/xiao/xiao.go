@@ -10,6 +10,8 @@
 package xiao
 
 import (
+	"errors"
+
 	"github.com/OpenWhiteBox/primitives/matrix"
 	"github.com/OpenWhiteBox/primitives/table"
 )
@@ -34,6 +36,23 @@ func (constr Construction) Decrypt(dst, src []byte) {
 	constr.crypt(dst, src)
 }
 
+// Reencode composes additional linear external encodings onto the construction, without knowledge of its key or masks.
+// The returned construction computes b(constr(a(x))): if constr has masks inputMask and outputMask, the returned
+// construction has masks inputMask.Compose(a) and b.Compose(outputMask). a and b must be invertible 128x128 matrices.
+func (constr *Construction) Reencode(a, b matrix.Matrix) (Construction, error) {
+	if _, ok := a.Invert(); !ok {
+		return Construction{}, errors.New("input encoding isn't invertible")
+	} else if _, ok := b.Invert(); !ok {
+		return Construction{}, errors.New("output encoding isn't invertible")
+	}
+
+	out := *constr
+	out.ShiftRows[0] = constr.ShiftRows[0].Compose(a)
+	out.FinalMask = b.Compose(constr.FinalMask)
+
+	return out, nil
+}
+
 func (constr *Construction) crypt(dst, src []byte) {
 	copy(dst, src)
 
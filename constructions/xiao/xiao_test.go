@@ -99,6 +99,95 @@ func TestDecrypt(t *testing.T) {
 	}
 }
 
+func TestReencode(t *testing.T) {
+	constr1, inputMask, outputMask := GenerateEncryptionKeys(key, seed, common.MatchingMasks{})
+	_, a, b := GenerateEncryptionKeys(key, key, common.IndependentMasks{common.RandomMask, common.RandomMask})
+
+	constr2, err := constr1.Reencode(a, b)
+	if err != nil {
+		t.Fatalf("Reencode returned error: %v", err)
+	}
+
+	inputInv, _ := inputMask.Compose(a).Invert()
+	outputInv, _ := b.Compose(outputMask).Invert()
+
+	cand, real := make([]byte, 16), make([]byte, 16)
+
+	constr2.Encrypt(cand, inputInv.Mul(matrix.Row(input)))
+	copy(cand, outputInv.Mul(matrix.Row(cand)))
+
+	c := saes.Construction{Key: key}
+	c.Encrypt(real, input)
+
+	if !bytes.Equal(real, cand) {
+		t.Fatalf("Real disagrees with result! %x != %x", real, cand)
+	}
+}
+
+func TestSplit(t *testing.T) {
+	constr, _, _ := GenerateEncryptionKeys(key, seed, common.IndependentMasks{common.RandomMask, common.RandomMask})
+
+	for _, round := range []int{1, 5, 9} {
+		client, server, err := constr.Split(round, seed)
+		if err != nil {
+			t.Fatalf("Split returned error: %v", err)
+		} else if len(client.ShiftRows) != round || len(server.ShiftRows) != 10-round {
+			t.Fatalf("Split at round %v gave pieces of the wrong size", round)
+		}
+
+		cand, real := make([]byte, 16), make([]byte, 16)
+
+		client.Encrypt(cand, input)
+		server.Encrypt(cand, cand)
+
+		constr.Encrypt(real, input)
+
+		if !bytes.Equal(real, cand) {
+			t.Fatalf("Real disagrees with result! %x != %x", real, cand)
+		}
+	}
+
+	if _, _, err := constr.Split(0, seed); err == nil {
+		t.Fatalf("Split accepted round 0")
+	} else if _, _, err := constr.Split(10, seed); err == nil {
+		t.Fatalf("Split accepted round 10")
+	}
+}
+
+func TestPiecePersistence(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping the persistence test in short mode!")
+	}
+
+	constr, _, _ := GenerateEncryptionKeys(key, seed, common.IndependentMasks{common.RandomMask, common.RandomMask})
+	client1, server1, _ := constr.Split(3, seed)
+
+	client2, err := ParsePiece(client1.Serialize())
+	if err != nil {
+		t.Fatalf("ParsePiece returned error: %v", err)
+	}
+
+	server2, err := ParsePiece(server1.Serialize())
+	if err != nil {
+		t.Fatalf("ParsePiece returned error: %v", err)
+	}
+
+	cand, real := make([]byte, 16), make([]byte, 16)
+
+	client2.Encrypt(cand, input)
+	server2.Encrypt(cand, cand)
+
+	constr.Encrypt(real, input)
+
+	if !bytes.Equal(real, cand) {
+		t.Fatalf("Real disagrees with result! %x != %x", real, cand)
+	}
+
+	if _, err := ParsePiece(constr.Serialize()); err == nil {
+		t.Fatalf("ParsePiece accepted a whole construction")
+	}
+}
+
 func TestPersistence(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping the persistence test in short mode!")
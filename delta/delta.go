@@ -0,0 +1,289 @@
+// Package delta implements a binary patch format between two serialized constructions of the same type, for shipping
+// updates to fielded devices.
+//
+// Re-randomizing some rounds of a construction, or re-encoding it, only changes a few of its tables. A patch holds only
+// the bytes that changed, section by section, along with digests of the old and new constructions so that a device can
+// check that a patch applies to the construction it has and that it produced the right one.
+//
+// A patch is laid out as follows, with all integers big-endian:
+//
+//	magic     [4]byte   "WBDF"
+//	version   uint8     Version
+//	type      uint8     common.ConstructionType of both constructions
+//	count     uint32    number of sections in the new construction
+//	base      [32]byte  SHA-256 of the old construction
+//	target    [32]byte  SHA-256 of the new construction
+//	sections  [count] of {size uint64, ops uint32, [ops] of {offset uint64, length uint32, data [length]byte}}
+//	checksum  [32]byte  SHA-256 of everything above
+//
+// Each section of the new construction is built by taking the same section of the old one, truncated or zero-padded to
+// size, and copying the data of each op over it at offset.
+package delta
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/OpenWhiteBox/AES/constructions/common"
+)
+
+// Version is the version of the patch format written by Diff.
+const Version = 1
+
+// magic is the first four bytes of every patch.
+var magic = []byte("WBDF")
+
+const (
+	headerSize = 4 + 1 + 1 + 4 + 2*sha256.Size
+	opSize     = 8 + 4
+)
+
+// op overwrites the bytes of a section starting at offset with data.
+type op struct {
+	offset uint64
+	data   []byte
+}
+
+// Patch is a parsed patch.
+type Patch struct {
+	Type   common.ConstructionType
+	Base   [32]byte // SHA-256 of the old construction.
+	Target [32]byte // SHA-256 of the new construction.
+
+	sizes []uint64
+	ops   [][]op
+}
+
+// Diff returns a patch which turns the serialized construction from into to. It returns an error if either isn't a
+// valid container, or if they hold different types of constructions.
+func Diff(from, to []byte) ([]byte, error) {
+	fromType, fromSections, err := sections(from)
+	if err != nil {
+		return nil, fmt.Errorf("old construction: %v", err)
+	}
+	toType, toSections, err := sections(to)
+	if err != nil {
+		return nil, fmt.Errorf("new construction: %v", err)
+	} else if fromType != toType {
+		return nil, fmt.Errorf("can't diff a %v construction against a %v construction", fromType, toType)
+	}
+
+	p := &Patch{
+		Type:   toType,
+		Base:   sha256.Sum256(from),
+		Target: sha256.Sum256(to),
+
+		sizes: make([]uint64, len(toSections)),
+		ops:   make([][]op, len(toSections)),
+	}
+
+	for i, section := range toSections {
+		p.sizes[i] = uint64(len(section))
+		p.ops[i] = diffSection(resize(base(fromSections, i), len(section)), section)
+	}
+
+	return p.serialize(), nil
+}
+
+// Parse parses a patch. It returns an error if the patch is malformed, corrupted, or of a different version.
+func Parse(in []byte) (*Patch, error) {
+	if len(in) < headerSize+sha256.Size {
+		return nil, errors.New("patch is too short")
+	}
+
+	body, checksum := in[:len(in)-sha256.Size], in[len(in)-sha256.Size:]
+	if real := sha256.Sum256(body); !bytes.Equal(real[:], checksum) {
+		return nil, errors.New("patch checksum mismatch")
+	} else if !bytes.Equal(body[:len(magic)], magic) {
+		return nil, errors.New("not a patch")
+	} else if version := body[len(magic)]; version != Version {
+		return nil, fmt.Errorf("unsupported patch version %v", version)
+	}
+
+	p := &Patch{Type: common.ConstructionType(body[len(magic)+1])}
+	count := binary.BigEndian.Uint32(body[len(magic)+2:])
+	copy(p.Base[:], body[len(magic)+6:])
+	copy(p.Target[:], body[len(magic)+6+sha256.Size:])
+
+	rest := body[headerSize:]
+	for i := uint32(0); i < count; i++ {
+		if len(rest) < 8+4 {
+			return nil, fmt.Errorf("section %v is truncated", i)
+		}
+
+		size, n := binary.BigEndian.Uint64(rest), binary.BigEndian.Uint32(rest[8:])
+		rest = rest[8+4:]
+
+		ops := []op{}
+		for j := uint32(0); j < n; j++ {
+			if len(rest) < opSize {
+				return nil, fmt.Errorf("section %v is truncated", i)
+			}
+
+			offset, length := binary.BigEndian.Uint64(rest), uint64(binary.BigEndian.Uint32(rest[8:]))
+			rest = rest[opSize:]
+
+			if uint64(len(rest)) < length {
+				return nil, fmt.Errorf("section %v is truncated", i)
+			} else if offset > size || length > size-offset {
+				return nil, fmt.Errorf("section %v writes out of bounds", i)
+			}
+
+			ops = append(ops, op{offset, rest[:length]})
+			rest = rest[length:]
+		}
+
+		p.sizes, p.ops = append(p.sizes, size), append(p.ops, ops)
+	}
+
+	if len(rest) != 0 {
+		return nil, errors.New("patch has trailing data")
+	}
+
+	return p, nil
+}
+
+// Verify checks that the patch applies to the serialized construction old: that it's well-formed, and that old is the
+// construction it was made against.
+func Verify(old, patch []byte) error {
+	_, err := parseFor(old, patch)
+	return err
+}
+
+// Apply applies the patch to the serialized construction old and returns the new serialized construction. It returns
+// an error if the patch doesn't verify against old, or if the result isn't the construction the patch was made for.
+func Apply(old, patch []byte) ([]byte, error) {
+	p, err := parseFor(old, patch)
+	if err != nil {
+		return nil, err
+	}
+
+	_, oldSections, err := sections(old)
+	if err != nil {
+		return nil, err
+	}
+
+	newSections := make([][]byte, len(p.sizes))
+	for i, size := range p.sizes {
+		// The checksum isn't a MAC, so don't trust sizes enough to allocate more than the inputs could account for.
+		if size > uint64(len(old))+uint64(len(patch)) {
+			return nil, fmt.Errorf("section %v is too large", i)
+		}
+
+		newSections[i] = resize(base(oldSections, i), int(size))
+		for _, o := range p.ops[i] {
+			copy(newSections[i][o.offset:], o.data)
+		}
+	}
+
+	out := common.Seal(p.Type, newSections...)
+	if sha256.Sum256(out) != p.Target {
+		return nil, errors.New("patched construction doesn't match the patch's target")
+	}
+
+	return out, nil
+}
+
+// parseFor parses patch and checks that it was made against old.
+func parseFor(old, patch []byte) (*Patch, error) {
+	p, err := Parse(patch)
+	if err != nil {
+		return nil, err
+	} else if sha256.Sum256(old) != p.Base {
+		return nil, errors.New("patch wasn't made against this construction")
+	}
+
+	return p, nil
+}
+
+func (p *Patch) serialize() []byte {
+	out := make([]byte, headerSize, headerSize+sha256.Size)
+
+	copy(out, magic)
+	out[len(magic)], out[len(magic)+1] = Version, byte(p.Type)
+	binary.BigEndian.PutUint32(out[len(magic)+2:], uint32(len(p.sizes)))
+	copy(out[len(magic)+6:], p.Base[:])
+	copy(out[len(magic)+6+sha256.Size:], p.Target[:])
+
+	for i, size := range p.sizes {
+		out = appendUint64(out, size)
+		out = appendUint32(out, uint32(len(p.ops[i])))
+
+		for _, o := range p.ops[i] {
+			out = appendUint64(out, o.offset)
+			out = appendUint32(out, uint32(len(o.data)))
+			out = append(out, o.data...)
+		}
+	}
+
+	checksum := sha256.Sum256(out)
+	return append(out, checksum[:]...)
+}
+
+// diffSection returns the ops which turn from into to, which must be the same length. A run of unchanged bytes shorter
+// than an op's header doesn't split an op, since the new op would cost more than carrying the unchanged bytes.
+func diffSection(from, to []byte) (ops []op) {
+	for i := 0; i < len(to); {
+		if from[i] == to[i] {
+			i++
+			continue
+		}
+
+		start, end := i, i+1
+		for j := end; j < len(to) && j-end < opSize; j++ {
+			if from[j] != to[j] {
+				end = j + 1
+			}
+		}
+
+		ops = append(ops, op{uint64(start), to[start:end]})
+		i = end
+	}
+
+	return
+}
+
+// sections returns the type and sections of a serialized construction.
+func sections(in []byte) (common.ConstructionType, [][]byte, error) {
+	constrType, sizes, err := common.Header(in)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	out, err := common.Open(constrType, in, sizes...)
+	return constrType, out, err
+}
+
+// base returns the i-th section, or nothing if there isn't one.
+func base(sections [][]byte, i int) []byte {
+	if i < len(sections) {
+		return sections[i]
+	}
+
+	return nil
+}
+
+// resize returns a copy of in, truncated or zero-padded to size bytes.
+func resize(in []byte, size int) []byte {
+	out := make([]byte, size)
+	copy(out, in)
+
+	return out
+}
+
+func appendUint64(out []byte, x uint64) []byte {
+	buf := [8]byte{}
+	binary.BigEndian.PutUint64(buf[:], x)
+
+	return append(out, buf[:]...)
+}
+
+func appendUint32(out []byte, x uint32) []byte {
+	buf := [4]byte{}
+	binary.BigEndian.PutUint32(buf[:], x)
+
+	return append(out, buf[:]...)
+}
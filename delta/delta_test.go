@@ -0,0 +1,122 @@
+package delta
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/OpenWhiteBox/AES/constructions/common"
+	"github.com/OpenWhiteBox/AES/constructions/toy"
+)
+
+var (
+	key  = []byte{72, 101, 108, 108, 111, 32, 87, 111, 114, 108, 100, 33, 33, 33, 33, 33}
+	seed = []byte{38, 41, 142, 156, 29, 181, 23, 194, 21, 250, 223, 183, 210, 168, 214, 145}
+)
+
+func randomSections(r *rand.Rand, sizes ...int) [][]byte {
+	out := make([][]byte, len(sizes))
+	for i, size := range sizes {
+		out[i] = make([]byte, size)
+		r.Read(out[i])
+	}
+
+	return out
+}
+
+func TestDiff(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+
+	sections := randomSections(r, 4096, 4096, 4096)
+	old := common.Seal(common.Toy, sections...)
+
+	sections[1][100] ^= 0xff
+	sections[1][110] ^= 0xff
+	sections[2][4095] ^= 0xff
+	updated := common.Seal(common.Toy, sections...)
+
+	patch, err := Diff(old, updated)
+	if err != nil {
+		t.Fatalf("Diff returned error: %v", err)
+	} else if len(patch) > 256 {
+		t.Fatalf("Patch is %v bytes for three changed bytes", len(patch))
+	}
+
+	cand, err := Apply(old, patch)
+	if err != nil {
+		t.Fatalf("Apply returned error: %v", err)
+	} else if !bytes.Equal(cand, updated) {
+		t.Fatalf("Patched construction isn't the new construction!")
+	}
+}
+
+func TestDiffResize(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+
+	old := common.Seal(common.Full, randomSections(r, 100, 200)...)
+	updated := common.Seal(common.Full, randomSections(r, 300, 50, 10)...)
+
+	patch, err := Diff(old, updated)
+	if err != nil {
+		t.Fatalf("Diff returned error: %v", err)
+	}
+
+	cand, err := Apply(old, patch)
+	if err != nil {
+		t.Fatalf("Apply returned error: %v", err)
+	} else if !bytes.Equal(cand, updated) {
+		t.Fatalf("Patched construction isn't the new construction!")
+	}
+
+	if _, err := Diff(old, common.Seal(common.Toy)); err == nil {
+		t.Fatalf("Diff accepted constructions of different types")
+	}
+}
+
+func TestVerify(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+
+	old := common.Seal(common.Toy, randomSections(r, 1024)...)
+	updated := common.Seal(common.Toy, randomSections(r, 1024)...)
+
+	patch, _ := Diff(old, updated)
+
+	if err := Verify(old, patch); err != nil {
+		t.Fatalf("Verify returned error: %v", err)
+	} else if err := Verify(updated, patch); err == nil {
+		t.Fatalf("Verify accepted a patch made against a different construction")
+	} else if _, err := Apply(updated, patch); err == nil {
+		t.Fatalf("Apply accepted a patch made against a different construction")
+	}
+
+	patch[headerSize+20] ^= 0x01
+	if err := Verify(old, patch); err == nil {
+		t.Fatalf("Verify accepted a corrupted patch")
+	}
+}
+
+func TestReencodedToy(t *testing.T) {
+	constr1, _, _ := toy.GenerateKeys(key, seed)
+	_, a, b := toy.GenerateKeys(key, key)
+
+	constr2, err := constr1.Reencode(a.Forwards, b.Forwards)
+	if err != nil {
+		t.Fatalf("Reencode returned error: %v", err)
+	}
+
+	old, updated := constr1.Serialize(), constr2.Serialize()
+
+	patch, err := Diff(old, updated)
+	if err != nil {
+		t.Fatalf("Diff returned error: %v", err)
+	} else if len(patch) > len(updated)/2 {
+		t.Fatalf("Patch is %v bytes for a %v byte construction with two changed rounds", len(patch), len(updated))
+	}
+
+	cand, err := Apply(old, patch)
+	if err != nil {
+		t.Fatalf("Apply returned error: %v", err)
+	} else if !bytes.Equal(cand, updated) {
+		t.Fatalf("Patched construction isn't the new construction!")
+	}
+}
@@ -0,0 +1,113 @@
+package flat
+
+// chow evaluates a Chow construction over its flat tables.
+type chow struct {
+	inputMask, inputXOR   []byte
+	tboxTyi, highXOR      []byte
+	mbInverse, lowXOR     []byte
+	outputMask, outputXOR []byte
+}
+
+var (
+	shiftRows   = [16]int{0, 5, 10, 15, 4, 9, 14, 3, 8, 13, 2, 7, 12, 1, 6, 11}
+	unShiftRows = [16]int{0, 13, 10, 7, 4, 1, 14, 11, 8, 5, 2, 15, 12, 9, 6, 3}
+)
+
+func loadChow(tables [][]byte) (*chow, error) {
+	const (
+		blockMatrix = 16 * 256 * 16
+		blockXOR    = 32 * 15 * 256
+		step        = 9 * 16 * 256 * 4
+		stepXOR     = 9 * 32 * 3 * 256
+	)
+
+	err := checkSizes(Chow, tables, blockMatrix, blockXOR, step, stepXOR, step, stepXOR, blockMatrix, blockXOR)
+	if err != nil {
+		return nil, err
+	}
+
+	return &chow{tables[0], tables[1], tables[2], tables[3], tables[4], tables[5], tables[6], tables[7]}, nil
+}
+
+// BlockSize returns the block size of AES. (Necessary to implement cipher.Block.)
+func (c *chow) BlockSize() int { return 16 }
+
+// Encrypt encrypts the first block in src into dst. Dst and src may point at the same memory.
+func (c *chow) Encrypt(dst, src []byte) {
+	c.crypt(dst, src, &shiftRows)
+}
+
+// Decrypt decrypts the first block in src into dst. Dst and src may point at the same memory.
+func (c *chow) Decrypt(dst, src []byte) {
+	c.crypt(dst, src, &unShiftRows)
+}
+
+func (c *chow) crypt(dst, src []byte, shift *[16]int) {
+	state := [16]byte{}
+	copy(state[:], src[:16])
+
+	blockMatrix(c.inputMask, c.inputXOR, &state)
+
+	for round := 0; round < 9; round++ {
+		permute(&state, shift)
+
+		for pos := 0; pos < 16; pos += 4 {
+			word := state[pos : pos+4]
+
+			step(c.tboxTyi[4*256*(16*round+pos):], c.highXOR[256*3*(32*round+2*pos):], word)
+			step(c.mbInverse[4*256*(16*round+pos):], c.lowXOR[256*3*(32*round+2*pos):], word)
+		}
+	}
+
+	permute(&state, shift)
+
+	blockMatrix(c.outputMask, c.outputXOR, &state)
+
+	copy(dst[:16], state[:])
+}
+
+func permute(state *[16]byte, perm *[16]int) {
+	temp := *state
+	for i, j := range perm {
+		state[i] = temp[j]
+	}
+}
+
+// squash XORs the bytes a and b with the nibble XOR tables at xor. The table for the low nibble is stride tables after
+// the table for the high nibble.
+func squash(xor []byte, stride int, a, b byte) byte {
+	high := a&0xf0 | b>>4
+	low := a<<4 | b&0x0f
+
+	return xor[high]<<4 | xor[256*stride+int(low)]
+}
+
+// blockMatrix expands each byte of state into a block with mask, and then XORs the blocks together.
+func blockMatrix(mask, xor []byte, state *[16]byte) {
+	expanded := [16][16]byte{}
+	for i := 0; i < 16; i++ {
+		copy(expanded[i][:], mask[16*(256*i+int(state[i])):])
+	}
+
+	*state = expanded[0]
+	for i := 1; i < 16; i++ {
+		for pos := 0; pos < 16; pos++ {
+			state[pos] = squash(xor[256*(2*pos*15+i-1):], 15, state[pos], expanded[i][pos])
+		}
+	}
+}
+
+// step expands each byte of word into a word with tables, and then XORs the words together.
+func step(tables, xor, word []byte) {
+	expanded := [4][4]byte{}
+	for i := 0; i < 4; i++ {
+		copy(expanded[i][:], tables[4*(256*i+int(word[i])):])
+	}
+
+	copy(word, expanded[0][:])
+	for i := 1; i < 4; i++ {
+		for pos := 0; pos < 4; pos++ {
+			word[pos] = squash(xor[256*(2*pos*3+i-1):], 3, word[pos], expanded[i][pos])
+		}
+	}
+}
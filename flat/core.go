@@ -0,0 +1,110 @@
+//go:build tinygo || wbcore
+// +build tinygo wbcore
+
+package flat
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+// The core is built for TinyGo, or with the wbcore build tag. Its errors are preallocated so that loading doesn't
+// allocate either.
+var (
+	errMalformed = errors.New("malformed key package")
+	errWrongType = errors.New("key package holds the wrong type of construction")
+	errWrongSize = errors.New("key package table has the wrong size")
+)
+
+// view returns the i-th table of the key package in, which must be of type t and have the given size. Unlike Tables,
+// it doesn't allocate: the table is a sub-slice of in.
+func view(in []byte, t Type, i, size int) ([]byte, error) {
+	if len(in) < headerSize || !bytes.Equal(in[:4], magic) || in[4] != Version {
+		return nil, errMalformed
+	} else if Type(in[5]) != t {
+		return nil, errWrongType
+	} else if uint32(i) >= binary.BigEndian.Uint32(in[8:]) || len(in) < headerSize+8*(i+1) {
+		return nil, errMalformed
+	}
+
+	offset := uint64(binary.BigEndian.Uint32(in[headerSize+8*i:]))
+	length := uint64(binary.BigEndian.Uint32(in[headerSize+8*i+4:]))
+
+	if offset+length > uint64(len(in)) {
+		return nil, errMalformed
+	} else if length != uint64(size) {
+		return nil, errWrongSize
+	}
+
+	return in[offset : offset+length], nil
+}
+
+// ChowCore evaluates a Chow key package in place, over views into the caller's buffer. The key package isn't copied,
+// so it can stay wherever the caller keeps it--in flash, on targets with tiny heaps--and it shouldn't be modified while
+// the core is in use. Evaluating uses only the stack and the caller's buffers, with no allocation or interface
+// dispatch.
+type ChowCore struct {
+	c chow
+}
+
+// Load validates the Chow key package in and points core at its tables.
+func (core *ChowCore) Load(in []byte) error {
+	const (
+		blockMatrix = 16 * 256 * 16
+		blockXOR    = 32 * 15 * 256
+		step        = 9 * 16 * 256 * 4
+		stepXOR     = 9 * 32 * 3 * 256
+	)
+
+	sizes := [8]int{blockMatrix, blockXOR, step, stepXOR, step, stepXOR, blockMatrix, blockXOR}
+	tables := [8][]byte{}
+
+	for i, size := range sizes {
+		table, err := view(in, Chow, i, size)
+		if err != nil {
+			return err
+		}
+		tables[i] = table
+	}
+
+	core.c = chow{tables[0], tables[1], tables[2], tables[3], tables[4], tables[5], tables[6], tables[7]}
+	return nil
+}
+
+// Encrypt encrypts the block src into dst. Dst and src may point at the same memory.
+func (core *ChowCore) Encrypt(dst, src *[16]byte) {
+	core.c.crypt(dst[:], src[:], &shiftRows)
+}
+
+// Decrypt decrypts the block src into dst. Dst and src may point at the same memory.
+func (core *ChowCore) Decrypt(dst, src *[16]byte) {
+	core.c.crypt(dst[:], src[:], &unShiftRows)
+}
+
+// ToyCore evaluates a toy key package in place. Like ChowCore, it holds views into the caller's buffer, and evaluating
+// it doesn't allocate or dispatch through interfaces.
+type ToyCore struct {
+	t toy
+}
+
+// Load validates the toy key package in and points core at its tables.
+func (core *ToyCore) Load(in []byte) error {
+	linear, err := view(in, Toy, 0, 11*128*16)
+	if err != nil {
+		return err
+	}
+
+	constant, err := view(in, Toy, 1, 11*16)
+	if err != nil {
+		return err
+	}
+
+	core.t = toy{linear: linear, constant: constant}
+	return nil
+}
+
+// Encrypt encrypts the block src into dst. Dst and src may point at the same memory.
+func (core *ToyCore) Encrypt(dst, src *[16]byte) {
+	core.t.Encrypt(dst[:], src[:])
+}
@@ -0,0 +1,105 @@
+//go:build tinygo || wbcore
+// +build tinygo wbcore
+
+package flat
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+// randomChow returns a Chow key package with random tables.
+func randomChow(r *rand.Rand) []byte {
+	blockMatrix, blockXOR, step, stepXOR := 16*256*16, 32*15*256, 9*16*256*4, 9*32*3*256
+	sizes := []int{blockMatrix, blockXOR, step, stepXOR, step, stepXOR, blockMatrix, blockXOR}
+	tables := make([][]byte, 8)
+
+	for i := range tables {
+		tables[i] = make([]byte, sizes[i])
+		r.Read(tables[i])
+
+		// XOR tables only output nibbles.
+		if i%2 == 1 {
+			for j := range tables[i] {
+				tables[i][j] &= 0x0f
+			}
+		}
+	}
+
+	return Build(Chow, tables...)
+}
+
+func TestChowCore(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	in := randomChow(r)
+
+	core := &ChowCore{}
+	if err := core.Load(in); err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	loaded, _ := Load(in)
+
+	block, real, cand := [16]byte{}, make([]byte, 16), [16]byte{}
+	r.Read(block[:])
+
+	loaded.Encrypt(real, block[:])
+	core.Encrypt(&cand, &block)
+
+	if !bytes.Equal(real, cand[:]) {
+		t.Fatalf("Real disagrees with core! %x != %x", real, cand)
+	}
+
+	loaded.Decrypt(real, block[:])
+	core.Decrypt(&cand, &block)
+
+	if !bytes.Equal(real, cand[:]) {
+		t.Fatalf("Real disagrees with core! %x != %x", real, cand)
+	}
+
+	if allocs := testing.AllocsPerRun(100, func() { core.Encrypt(&cand, &block) }); allocs != 0 {
+		t.Fatalf("Encrypt allocated %v times!", allocs)
+	} else if allocs := testing.AllocsPerRun(100, func() { core.Load(in) }); allocs != 0 {
+		t.Fatalf("Load allocated %v times!", allocs)
+	}
+
+	// The core evaluates over the caller's buffer, rather than a copy of it.
+	if _, tables, _ := Tables(in); &core.c.inputMask[0] != &tables[0][0] {
+		t.Fatal("Core copied the key package!")
+	}
+
+	if err := core.Load(Build(Toy)); err == nil {
+		t.Fatal("Load accepted the wrong type of key package!")
+	}
+}
+
+func TestToyCore(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+
+	linear, constant := make([]byte, 0, 11*128*16), make([]byte, 11*16)
+	for round := 0; round < 11; round++ {
+		linear = append(linear, randomLayer(r)...)
+	}
+	r.Read(constant)
+	in := Build(Toy, linear, constant)
+
+	core := &ToyCore{}
+	if err := core.Load(in); err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	loaded, _ := Load(in)
+
+	block, real, cand := [16]byte{}, make([]byte, 16), [16]byte{}
+	r.Read(block[:])
+
+	loaded.Encrypt(real, block[:])
+	core.Encrypt(&cand, &block)
+
+	if !bytes.Equal(real, cand[:]) {
+		t.Fatalf("Real disagrees with core! %x != %x", real, cand)
+	}
+
+	if allocs := testing.AllocsPerRun(100, func() { core.Encrypt(&cand, &block) }); allocs != 0 {
+		t.Fatalf("Encrypt allocated %v times!", allocs)
+	}
+}
@@ -0,0 +1,223 @@
+// Package flat implements a flat binary format for white-box constructions, along with a reference loader which
+// evaluates a construction directly over its serialized tables. Unlike the format written by each construction's
+// Serialize method, every table is stored fully expanded--one entry per input, in input order--so a loader in another
+// language only needs to index into byte arrays. The package has no dependencies beyond the standard library.
+//
+// A key package is laid out as follows, with all integers big-endian:
+//
+//	magic     [4]byte  "WBFL"
+//	version   uint8    Version
+//	type      uint8    Type of the construction
+//	reserved  [2]byte  zero
+//	count     uint32   number of tables
+//	offsets   [count] of {offset, length uint32}, the location of each table in bytes, from the start of the package
+//	tables    each table, starting on a 16-byte boundary, padded with zeros
+//
+// The tables of each type of construction are listed below. Multi-dimensional tables are stored in row-major order.
+// Matrices are stored as rows of bits, where bit i of a row is (row[i/8] >> (i%8)) & 1, and the i-th bit of the
+// product of a matrix and a vector is the parity of the i-th row AND the vector.
+//
+// Chow:
+//
+//	0 input mask    [16][256][16]  position, input byte, output block
+//	1 input xor     [32][15][256]  nibble-wise position, gate, input byte -> nibble
+//	2 tbox tyi      [9][16][256][4]  round, position, input byte, output word
+//	3 high xor      [9][32][3][256]  round, nibble-wise position, gate, input byte -> nibble
+//	4 mb inverse    [9][16][256][4]
+//	5 low xor       [9][32][3][256]
+//	6 output mask   [16][256][16]
+//	7 output xor    [32][15][256]
+//
+// Xiao:
+//
+//	0 shift rows    [10][128][16]  round, matrix
+//	1 tbox mixcol   [10][8][65536][4]  round, table, 256*first input byte + second input byte, output word
+//	2 final mask    [128][16]
+//
+// Toy:
+//
+//	0 linear        [11][128][16]  round, matrix
+//	1 constant      [11][16]
+//
+// A toy key package only stores the encryption direction. Load inverts each linear layer to decrypt, and rejects a
+// package with a layer that isn't invertible.
+//
+// Full:
+//
+//	0-40 layers     each is {rows/8, columns/8 uint8, matrix [rows][columns/8], constant [rows/8]}
+//
+// The evaluation of each construction mirrors its implementation in the constructions/ packages. A key package has no
+// checksum; it's meant to be mapped into memory and evaluated in place, so integrity should be checked when it's
+// delivered.
+//
+// For embedded targets, building with TinyGo or the wbcore build tag adds ChowCore and ToyCore, which evaluate a key
+// package in place, without copying it or allocating. ToyCore only encrypts, since it has nowhere to keep the inverted
+// layers.
+package flat
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Version is the version of the format written by Build.
+const Version = 1
+
+// magic is the first four bytes of every key package.
+var magic = []byte("WBFL")
+
+// headerSize is the size of the fixed part of the header, before the offsets.
+const headerSize = 4 + 1 + 1 + 2 + 4
+
+// Type identifies which white-box construction a key package holds. The values are the same as those of
+// common.ConstructionType.
+type Type byte
+
+const (
+	Chow Type = iota + 1
+	Xiao
+	Toy
+	Full
+)
+
+func (t Type) String() string {
+	switch t {
+	case Chow:
+		return "chow"
+	case Xiao:
+		return "xiao"
+	case Toy:
+		return "toy"
+	case Full:
+		return "full"
+	default:
+		return fmt.Sprintf("Type(%d)", byte(t))
+	}
+}
+
+// align rounds n up to a multiple of 16.
+func align(n int) int {
+	return (n + 15) &^ 15
+}
+
+// Build packs the tables of a construction into a key package.
+func Build(t Type, tables ...[]byte) []byte {
+	size := align(headerSize + 8*len(tables))
+	for _, table := range tables {
+		size += align(len(table))
+	}
+
+	out := make([]byte, size)
+
+	copy(out, magic)
+	out[4], out[5] = Version, byte(t)
+	binary.BigEndian.PutUint32(out[8:], uint32(len(tables)))
+
+	base := align(headerSize + 8*len(tables))
+	for i, table := range tables {
+		binary.BigEndian.PutUint32(out[headerSize+8*i:], uint32(base))
+		binary.BigEndian.PutUint32(out[headerSize+8*i+4:], uint32(len(table)))
+
+		copy(out[base:], table)
+		base += align(len(table))
+	}
+
+	return out
+}
+
+// Tables reads the header of a key package. It returns the type of construction in the package and its tables, which
+// are sub-slices of in. It returns an error if the header is malformed or any table is out of bounds.
+func Tables(in []byte) (t Type, tables [][]byte, err error) {
+	if len(in) < headerSize {
+		return 0, nil, errors.New("key package is too short")
+	} else if !bytes.Equal(in[:4], magic) {
+		return 0, nil, errors.New("not a flat key package")
+	} else if in[4] != Version {
+		return 0, nil, fmt.Errorf("unsupported key package version %v", in[4])
+	}
+
+	t = Type(in[5])
+
+	count := uint64(binary.BigEndian.Uint32(in[8:]))
+	if uint64(len(in)) < headerSize+8*count {
+		return 0, nil, errors.New("key package offsets are truncated")
+	}
+
+	tables = make([][]byte, count)
+	for i := range tables {
+		offset := uint64(binary.BigEndian.Uint32(in[headerSize+8*i:]))
+		length := uint64(binary.BigEndian.Uint32(in[headerSize+8*i+4:]))
+
+		if offset+length > uint64(len(in)) {
+			return 0, nil, fmt.Errorf("table %v is out of bounds", i)
+		}
+
+		tables[i] = in[offset : offset+length]
+	}
+
+	return t, tables, nil
+}
+
+// Load returns a cipher.Block which evaluates the construction in the key package in. The tables aren't copied, so in
+// shouldn't be modified while the cipher is in use. Like the constructions it's loaded from, the cipher still has the
+// construction's input and output masks.
+func Load(in []byte) (cipher.Block, error) {
+	t, tables, err := Tables(in)
+	if err != nil {
+		return nil, err
+	}
+
+	switch t {
+	case Chow:
+		return loadChow(tables)
+	case Xiao:
+		return loadXiao(tables)
+	case Toy:
+		return loadToy(tables)
+	case Full:
+		return loadFull(tables)
+	default:
+		return nil, fmt.Errorf("unknown construction type %v", t)
+	}
+}
+
+// checkSizes returns an error if tables don't have the expected sizes.
+func checkSizes(t Type, tables [][]byte, sizes ...int) error {
+	if len(tables) != len(sizes) {
+		return fmt.Errorf("%v key package has %v tables, expected %v", t, len(tables), len(sizes))
+	}
+
+	for i, size := range sizes {
+		if len(tables[i]) != size {
+			return fmt.Errorf("table %v of %v key package has the wrong size", i, t)
+		}
+	}
+
+	return nil
+}
+
+// mul computes the product of the matrix m with rows of len(in) bytes and the vector in, and writes it to dst.
+func mul(dst, m, in []byte) {
+	width := len(in)
+
+	for i := 0; i < len(dst); i++ {
+		dst[i] = 0
+	}
+
+	for i := 0; i < len(m)/width; i++ {
+		row, parity := m[width*i:width*(i+1)], byte(0)
+		for j, b := range row {
+			parity ^= b & in[j]
+		}
+
+		// Fold the parity of the byte into its lowest bit.
+		parity ^= parity >> 4
+		parity ^= parity >> 2
+		parity ^= parity >> 1
+
+		dst[i/8] |= (parity & 1) << uint(i%8)
+	}
+}
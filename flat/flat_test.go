@@ -0,0 +1,141 @@
+package flat
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+// randomLayer returns a random invertible 128x128 matrix, built by adding random rows of the identity matrix to each
+// other.
+func randomLayer(r *rand.Rand) []byte {
+	m := make([]byte, 128*16)
+	for i := 0; i < 128; i++ {
+		m[16*i+i/8] = 1 << uint(i%8)
+	}
+
+	for n := 0; n < 4096; n++ {
+		i, j := r.Intn(128), r.Intn(128)
+		if i == j {
+			continue
+		}
+
+		for k := 0; k < 16; k++ {
+			m[16*i+k] ^= m[16*j+k]
+		}
+	}
+
+	return m
+}
+
+func TestTables(t *testing.T) {
+	a, b := []byte{1, 2, 3}, bytes.Repeat([]byte{4}, 20)
+
+	built := Build(Toy, a, b)
+	if len(built)%16 != 0 {
+		t.Fatalf("Key package isn't aligned! %v", len(built))
+	}
+
+	ty, tables, err := Tables(built)
+	if err != nil {
+		t.Fatalf("Tables returned error: %v", err)
+	} else if ty != Toy || len(tables) != 2 {
+		t.Fatalf("Tables disagrees with key package! %v, %v tables", ty, len(tables))
+	} else if !bytes.Equal(a, tables[0]) || !bytes.Equal(b, tables[1]) {
+		t.Fatalf("Tables disagree with originals! %x, %x != %x, %x", a, b, tables[0], tables[1])
+	}
+
+	if _, _, err := Tables(built[:40]); err == nil {
+		t.Fatal("Tables accepted a truncated key package!")
+	}
+
+	if _, err := Load(built); err == nil {
+		t.Fatal("Load accepted tables of the wrong size!")
+	}
+}
+
+func TestMul(t *testing.T) {
+	// A matrix which swaps the first two bits and leaves the rest alone.
+	m := make([]byte, 128*16)
+	for i := 0; i < 128; i++ {
+		j := i
+		if i < 2 {
+			j = 1 - i
+		}
+
+		m[16*i+j/8] = 1 << uint(j%8)
+	}
+
+	in, out := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}, make([]byte, 16)
+	real := []byte{2, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+
+	mul(out, m, in)
+
+	if !bytes.Equal(real, out) {
+		t.Fatalf("Real disagrees with result! %x != %x", real, out)
+	}
+}
+
+func TestInverse(t *testing.T) {
+	for i := 1; i < 256; i++ {
+		if gfMul(byte(i), inverse[i]) != 1 {
+			t.Fatalf("Inverse of %x is wrong! %x", i, inverse[i])
+		}
+	}
+}
+
+func TestInvert(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+
+	m, inv := randomLayer(r), make([]byte, 128*16)
+	if !invert(inv, m) {
+		t.Fatal("Invert rejected an invertible matrix!")
+	}
+
+	in, temp, out := make([]byte, 16), make([]byte, 16), make([]byte, 16)
+	r.Read(in)
+
+	mul(temp, m, in)
+	mul(out, inv, temp)
+
+	if !bytes.Equal(in, out) {
+		t.Fatalf("Inverse doesn't invert matrix! %x != %x", in, out)
+	}
+
+	// Duplicating a row makes the matrix singular.
+	copy(m[16:32], m[:16])
+	if invert(inv, m) {
+		t.Fatal("Invert accepted a singular matrix!")
+	}
+}
+
+func TestToyDecrypt(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+
+	linear, constant := make([]byte, 0, 11*128*16), make([]byte, 11*16)
+	for round := 0; round < 11; round++ {
+		linear = append(linear, randomLayer(r)...)
+	}
+	r.Read(constant)
+
+	loaded, err := Load(Build(Toy, linear, constant))
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+
+	in, out := make([]byte, 16), make([]byte, 16)
+	r.Read(in)
+
+	loaded.Encrypt(out, in)
+	loaded.Decrypt(out, out)
+
+	if !bytes.Equal(in, out) {
+		t.Fatalf("Decrypt doesn't invert Encrypt! %x != %x", in, out)
+	}
+
+	// A key package with a singular layer can't decrypt.
+	copy(linear[16:32], linear[:16])
+	if _, err := Load(Build(Toy, linear, constant)); err == nil {
+		t.Fatal("Load accepted a toy key package with a singular layer!")
+	}
+}
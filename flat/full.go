@@ -0,0 +1,85 @@
+package flat
+
+import (
+	"fmt"
+)
+
+// fullLayer is one affine layer of a full construction.
+type fullLayer struct {
+	linear, constant []byte
+	rows, columns    int // Both in bytes.
+}
+
+// full evaluates a full construction over its flat tables.
+type full [41]fullLayer
+
+func loadFull(tables [][]byte) (*full, error) {
+	if len(tables) != 41 {
+		return nil, fmt.Errorf("%v key package has %v tables, expected 41", Full, len(tables))
+	}
+
+	out := &full{}
+
+	for i, table := range tables {
+		if len(table) < 2 {
+			return nil, fmt.Errorf("table %v of %v key package has the wrong size", i, Full)
+		}
+
+		rows, columns := int(table[0]), int(table[1])
+		if len(table) != 2+8*rows*columns+rows {
+			return nil, fmt.Errorf("table %v of %v key package has the wrong size", i, Full)
+		}
+
+		out[i] = fullLayer{table[2 : 2+8*rows*columns], table[2+8*rows*columns:], rows, columns}
+	}
+
+	// Each layer's output, after compression, has to be the next layer's input.
+	if out[0].columns != 16 || out[40].rows != 16 {
+		return nil, fmt.Errorf("%v key package has the wrong block size", Full)
+	}
+	for i := 0; i < 40; i++ {
+		if compressed := out[i].rows - out[i+1].columns; compressed < 0 || 2*compressed > out[i].rows {
+			return nil, fmt.Errorf("layers %v and %v of %v key package don't fit together", i, i+1, Full)
+		}
+	}
+
+	return out, nil
+}
+
+// BlockSize returns the block size of AES. (Necessary to implement cipher.Block.)
+func (f *full) BlockSize() int { return 16 }
+
+// Encrypt encrypts the first block in src into dst. Dst and src may point at the same memory.
+func (f *full) Encrypt(dst, src []byte) {
+	state := append([]byte{}, src[:16]...)
+
+	for i, layer := range f {
+		temp := make([]byte, layer.rows)
+		mul(temp, layer.linear, state)
+		for j := range temp {
+			temp[j] ^= layer.constant[j]
+		}
+
+		if i == len(f)-1 {
+			state = temp
+			break
+		}
+
+		// The first part of the output is compressed by ANDing neighboring bits, and the rest is passed through.
+		cs := layer.rows - f[i+1].columns
+		state = make([]byte, f[i+1].columns)
+
+		for j := 0; j < 8*cs; j++ {
+			b1 := temp[(2*j+0)/8] >> uint((2*j+0)%8)
+			b2 := temp[(2*j+1)/8] >> uint((2*j+1)%8)
+
+			state[j/8] |= (b1 & b2 & 1) << uint(j%8)
+		}
+		copy(state[cs:], temp[2*cs:])
+	}
+
+	copy(dst[:16], state)
+}
+
+// Decrypt is not implemented.
+func (f *full) Decrypt(_, _ []byte) {}
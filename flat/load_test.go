@@ -0,0 +1,92 @@
+package flat_test
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"testing"
+
+	"github.com/OpenWhiteBox/AES/constructions/chow"
+	"github.com/OpenWhiteBox/AES/constructions/common"
+	"github.com/OpenWhiteBox/AES/constructions/full"
+	"github.com/OpenWhiteBox/AES/constructions/toy"
+	"github.com/OpenWhiteBox/AES/constructions/xiao"
+	"github.com/OpenWhiteBox/AES/flat"
+)
+
+var (
+	key   = []byte{72, 101, 108, 108, 111, 32, 87, 111, 114, 108, 100, 33, 33, 33, 33, 33}
+	seed  = []byte{38, 41, 142, 156, 29, 181, 23, 194, 21, 250, 223, 183, 210, 168, 214, 145}
+	input = []byte{99, 83, 224, 140, 9, 96, 225, 4, 205, 112, 183, 81, 186, 202, 208, 231}
+
+	opts = common.IndependentMasks{Input: common.RandomMask, Output: common.RandomMask}
+)
+
+// flattenable is a construction which can be serialized into a flat key package.
+type flattenable interface {
+	cipher.Block
+	Flatten() []byte
+}
+
+var flattenTests = []struct {
+	name     string
+	slow     bool
+	generate func() flattenable
+}{
+	{"chow encryption", false, func() flattenable {
+		constr, _, _ := chow.GenerateEncryptionKeys(key, seed, opts)
+		return &constr
+	}},
+	{"chow decryption", false, func() flattenable {
+		constr, _, _ := chow.GenerateDecryptionKeys(key, seed, opts)
+		return &constr
+	}},
+	{"xiao encryption", true, func() flattenable {
+		constr, _, _ := xiao.GenerateEncryptionKeys(key, seed, opts)
+		return &constr
+	}},
+	{"xiao decryption", true, func() flattenable {
+		constr, _, _ := xiao.GenerateDecryptionKeys(key, seed, opts)
+		return &constr
+	}},
+	{"toy", false, func() flattenable {
+		constr, _, _ := toy.GenerateKeys(key, seed)
+		return &constr
+	}},
+	{"full", false, func() flattenable {
+		constr, _, _ := full.GenerateKeys(key, seed)
+		return &constr
+	}},
+}
+
+// TestFlatten checks that every type of construction computes the same thing in both directions after it's flattened
+// and loaded. Full constructions can't decrypt, so both directions leave the output unchanged.
+func TestFlatten(t *testing.T) {
+	for _, test := range flattenTests {
+		if test.slow && testing.Short() {
+			continue
+		}
+
+		constr := test.generate()
+
+		loaded, err := flat.Load(constr.Flatten())
+		if err != nil {
+			t.Fatalf("%v: Load returned error: %v", test.name, err)
+		}
+
+		cand1, cand2 := make([]byte, 16), make([]byte, 16)
+
+		constr.Encrypt(cand1, input)
+		loaded.Encrypt(cand2, input)
+
+		if !bytes.Equal(cand1, cand2) {
+			t.Fatalf("%v: Real disagrees with flattened encryption! %x != %x", test.name, cand1, cand2)
+		}
+
+		constr.Decrypt(cand1, input)
+		loaded.Decrypt(cand2, input)
+
+		if !bytes.Equal(cand1, cand2) {
+			t.Fatalf("%v: Real disagrees with flattened decryption! %x != %x", test.name, cand1, cand2)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package flat
+
+import (
+	"errors"
+)
+
+// toy evaluates a toy construction over its flat tables. inverseLinear holds the inverse of each layer's matrix, for
+// decryption. It's computed when the key package is loaded, so it's nil in a ToyCore, which only encrypts.
+type toy struct {
+	linear, constant []byte
+	inverseLinear    []byte
+}
+
+// inverse is the multiplicative inverse of each element of GF(2^8), modulo AES' polynomial. Zero maps to zero.
+var inverse = func() (out [256]byte) {
+	for i := 1; i < 256; i++ {
+		for j := 1; j < 256; j++ {
+			if gfMul(byte(i), byte(j)) == 1 {
+				out[i] = byte(j)
+				break
+			}
+		}
+	}
+
+	return
+}()
+
+// gfMul multiplies two elements of GF(2^8), modulo x^8 + x^4 + x^3 + x + 1.
+func gfMul(a, b byte) (out byte) {
+	for b > 0 {
+		if b&1 == 1 {
+			out ^= a
+		}
+
+		a, b = a<<1^0x1b*(a>>7), b>>1
+	}
+
+	return
+}
+
+func loadToy(tables [][]byte) (*toy, error) {
+	if err := checkSizes(Toy, tables, 11*128*16, 11*16); err != nil {
+		return nil, err
+	}
+
+	inverseLinear := make([]byte, len(tables[0]))
+	for round := 0; round < 11; round++ {
+		layer := tables[0][128*16*round : 128*16*(round+1)]
+		if !invert(inverseLinear[128*16*round:128*16*(round+1)], layer) {
+			return nil, errors.New("toy key package has a layer which isn't invertible")
+		}
+	}
+
+	return &toy{tables[0], tables[1], inverseLinear}, nil
+}
+
+// invert computes the inverse of the 128x128 matrix m by Gauss-Jordan elimination, and writes it to dst. It returns
+// false if m isn't invertible.
+func invert(dst, m []byte) bool {
+	work := make([]byte, len(m))
+	copy(work, m)
+
+	for i := range dst {
+		dst[i] = 0
+	}
+	for i := 0; i < 128; i++ {
+		dst[16*i+i/8] = 1 << uint(i%8)
+	}
+
+	for col := 0; col < 128; col++ {
+		pivot := -1
+		for row := col; row < 128; row++ {
+			if work[16*row+col/8]>>uint(col%8)&1 == 1 {
+				pivot = row
+				break
+			}
+		}
+		if pivot == -1 {
+			return false
+		}
+
+		swapRows(work, col, pivot)
+		swapRows(dst, col, pivot)
+
+		for row := 0; row < 128; row++ {
+			if row != col && work[16*row+col/8]>>uint(col%8)&1 == 1 {
+				for k := 0; k < 16; k++ {
+					work[16*row+k] ^= work[16*col+k]
+					dst[16*row+k] ^= dst[16*col+k]
+				}
+			}
+		}
+	}
+
+	return true
+}
+
+// swapRows swaps rows i and j of a matrix with 16-byte rows.
+func swapRows(m []byte, i, j int) {
+	for k := 0; k < 16; k++ {
+		m[16*i+k], m[16*j+k] = m[16*j+k], m[16*i+k]
+	}
+}
+
+// BlockSize returns the block size of AES. (Necessary to implement cipher.Block.)
+func (t *toy) BlockSize() int { return 16 }
+
+// Encrypt encrypts the first block in src into dst. Dst and src may point at the same memory.
+func (t *toy) Encrypt(dst, src []byte) {
+	state, temp := [16]byte{}, [16]byte{}
+	copy(state[:], src[:16])
+
+	for round := 0; round < 11; round++ {
+		if round > 0 {
+			for pos := 0; pos < 16; pos++ {
+				state[pos] = inverse[state[pos]]
+			}
+		}
+
+		mul(temp[:], t.linear[128*16*round:128*16*(round+1)], state[:])
+		for pos := 0; pos < 16; pos++ {
+			state[pos] = temp[pos] ^ t.constant[16*round+pos]
+		}
+	}
+
+	copy(dst[:16], state[:])
+}
+
+// Decrypt decrypts the first block in src into dst. Dst and src may point at the same memory.
+func (t *toy) Decrypt(dst, src []byte) {
+	state, temp := [16]byte{}, [16]byte{}
+	copy(state[:], src[:16])
+
+	for round := 10; round >= 0; round-- {
+		for pos := 0; pos < 16; pos++ {
+			temp[pos] = state[pos] ^ t.constant[16*round+pos]
+		}
+		mul(state[:], t.inverseLinear[128*16*round:128*16*(round+1)], temp[:])
+
+		if round > 0 {
+			for pos := 0; pos < 16; pos++ {
+				state[pos] = inverse[state[pos]]
+			}
+		}
+	}
+
+	copy(dst[:16], state[:])
+}
@@ -0,0 +1,49 @@
+package flat
+
+// xiao evaluates a Xiao-Lai construction over its flat tables.
+type xiao struct {
+	shiftRows, tboxMixCol, finalMask []byte
+}
+
+func loadXiao(tables [][]byte) (*xiao, error) {
+	if err := checkSizes(Xiao, tables, 10*128*16, 10*8*65536*4, 128*16); err != nil {
+		return nil, err
+	}
+
+	return &xiao{tables[0], tables[1], tables[2]}, nil
+}
+
+// BlockSize returns the block size of AES. (Necessary to implement cipher.Block.)
+func (x *xiao) BlockSize() int { return 16 }
+
+// Encrypt encrypts the first block in src into dst. Dst and src may point at the same memory.
+func (x *xiao) Encrypt(dst, src []byte) {
+	x.crypt(dst, src)
+}
+
+// Decrypt decrypts the first block in src into dst. Dst and src may point at the same memory.
+func (x *xiao) Decrypt(dst, src []byte) {
+	x.crypt(dst, src)
+}
+
+func (x *xiao) crypt(dst, src []byte) {
+	state, temp := [16]byte{}, [16]byte{}
+	copy(state[:], src[:16])
+
+	for round := 0; round < 10; round++ {
+		// ShiftRows and re-encoding step.
+		mul(temp[:], x.shiftRows[128*16*round:128*16*(round+1)], state[:])
+
+		// Apply T-Boxes and MixColumns.
+		for pos := 0; pos < 16; pos += 4 {
+			a := x.tboxMixCol[4*(65536*(8*round+pos/2)+256*int(temp[pos+0])+int(temp[pos+1])):]
+			b := x.tboxMixCol[4*(65536*(8*round+pos/2+1)+256*int(temp[pos+2])+int(temp[pos+3])):]
+
+			for i := 0; i < 4; i++ {
+				state[pos+i] = a[i] ^ b[i]
+			}
+		}
+	}
+
+	mul(dst[:16], x.finalMask, state[:])
+}
@@ -0,0 +1,8 @@
+// Package formats imports Chow constructions generated by other tools into this repository's Chow construction, so
+// that the cryptanalyses in cryptanalysis/ can be pointed at them. The only format supported is the table layout of
+// Muir's tutorial, and only when its XOR tables are unencoded.
+//
+// Encoded XOR tables aren't supported. Other tools' XOR networks are trees, while this repository's are chains, so
+// encoded XOR tables can't be carried over table by table, and importing them would mean stripping their encodings
+// first. Dumps from generators that encode their XOR tables, which most do, are rejected.
+package formats
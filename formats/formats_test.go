@@ -0,0 +1,98 @@
+package formats
+
+import (
+	"bytes"
+	"crypto/aes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/OpenWhiteBox/AES/constructions/common"
+	"github.com/OpenWhiteBox/AES/constructions/saes"
+)
+
+var (
+	key   = []byte{72, 101, 108, 108, 111, 32, 87, 111, 114, 108, 100, 33, 33, 33, 33, 33}
+	input = []byte{99, 83, 224, 140, 9, 96, 225, 4, 205, 112, 183, 81, 186, 202, 208, 231}
+)
+
+// muirTables generates unencoded MuirTables for key, without mixing bijections.
+func muirTables(key []byte) *MuirTables {
+	mt := &MuirTables{}
+
+	constr := saes.Construction{Key: key}
+	roundKeys := constr.StretchedKey()
+	for k := 0; k < 10; k++ {
+		constr.ShiftRows(roundKeys[k])
+	}
+
+	for i := 0; i < 256; i++ {
+		for round := 0; round < 9; round++ {
+			for pos := 0; pos < 16; pos++ {
+				tbox := common.TBox{Constr: constr, KeyByte1: roundKeys[round][pos]}
+				tyi := common.TyiTable(pos % 4).Get(tbox.Get(byte(i)))
+
+				mbl := [4]byte{}
+				mbl[pos%4] = byte(i)
+
+				mt.TyBoxes[round][pos][i] = binary.BigEndian.Uint32(tyi[:])
+				mt.MBL[round][pos][i] = binary.BigEndian.Uint32(mbl[:])
+			}
+
+			for j := 0; j < 192; j++ {
+				mt.XOR[round][j][i/16][i%16] = uint8(i/16 ^ i%16)
+			}
+		}
+
+		for pos := 0; pos < 16; pos++ {
+			tbox := common.TBox{Constr: constr, KeyByte1: roundKeys[9][pos], KeyByte2: roundKeys[10][pos]}
+			mt.TBoxesLast[pos][i] = tbox.Get(byte(i))
+		}
+	}
+
+	return mt
+}
+
+func TestMuir(t *testing.T) {
+	tables := muirTables(key)
+
+	// Write each array separately, as the reference implementation would fwrite them.
+	dump := &bytes.Buffer{}
+	binary.Write(dump, binary.LittleEndian, &tables.TyBoxes)
+	binary.Write(dump, binary.LittleEndian, &tables.XOR)
+	binary.Write(dump, binary.LittleEndian, &tables.MBL)
+	binary.Write(dump, binary.LittleEndian, &tables.TBoxesLast)
+
+	if size := 9*16*256*4 + 9*192*16*16 + 9*16*256*4 + 16*256; dump.Len() != size {
+		t.Fatalf("Dump is %v bytes, not the %v bytes of the reference tables", dump.Len(), size)
+	}
+
+	mt, err := ParseMuir(dump.Bytes(), binary.LittleEndian)
+	if err != nil {
+		t.Fatalf("ParseMuir returned error: %v", err)
+	}
+
+	constr, err := mt.Construction()
+	if err != nil {
+		t.Fatalf("Construction returned error: %v", err)
+	}
+
+	cand, real := make([]byte, 16), make([]byte, 16)
+
+	constr.Encrypt(cand, input)
+
+	c, _ := aes.NewCipher(key)
+	c.Encrypt(real, input)
+
+	if !bytes.Equal(real, cand) {
+		t.Fatalf("Real disagrees with result! %x != %x", real, cand)
+	}
+
+	// Check that both XOR networks are checked for encodings.
+	for _, i := range []int{0, 96} {
+		mt.XOR[0][i][1][2] ^= 0x01
+		if _, err := mt.Construction(); err == nil {
+			t.Fatalf("Construction accepted encoded XOR table %v!", i)
+		}
+		mt.XOR[0][i][1][2] ^= 0x01
+	}
+}
@@ -0,0 +1,116 @@
+package formats
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/OpenWhiteBox/AES/constructions/chow"
+	"github.com/OpenWhiteBox/AES/constructions/common"
+)
+
+// MuirTables are the lookup tables of a Chow construction in the layout of Muir's tutorial, which reference
+// implementations of Chow et al.'s construction commonly follow. There are no external encodings: the first round's
+// tables take plaintext bytes and the last round's tables output ciphertext bytes.
+//
+// Each round is computed on the state after ShiftRows. The T-Boxes include the round keys, ShiftRows'd to match.
+// Words are 32-bit integers whose most significant byte is the first row of the column. Each 4-by-4 XOR table is
+// indexed by its two input nibbles.
+//
+// Like Chow's construction, each round has two XOR networks of 96 tables: tables 0 to 95 XOR together the outputs of
+// the round's TyBoxes, and tables 96 to 191 the outputs of its MBL tables. Each column takes 24 tables per
+// network--three for each of the 8 nibbles of its word.
+//
+// "A Tutorial on White-Box AES" by James A. Muir, https://eprint.iacr.org/2013/104.pdf
+type MuirTables struct {
+	TyBoxes    [9][16][256]uint32    // [round][position][input] T-Box composed with Ty_i and a mixing bijection.
+	XOR        [9][192][16][16]uint8 // [round][table][input nibble 1][input nibble 2]
+	MBL        [9][16][256]uint32    // [round][position][input] Inverse mixing bijections.
+	TBoxesLast [16][256]uint8        // [position][input] Final T-Boxes, including the last two round keys.
+}
+
+// ParseMuir parses a dump of MuirTables, with each field written in order as a raw array of integers in the given
+// byte order. (A C program can produce this by fwrite-ing its table arrays on a machine of that byte order.)
+func ParseMuir(in []byte, order binary.ByteOrder) (*MuirTables, error) {
+	mt := &MuirTables{}
+
+	if len(in) != binary.Size(mt) {
+		return nil, errors.New("table dump has the wrong size")
+	} else if err := binary.Read(bytes.NewReader(in), order, mt); err != nil {
+		return nil, err
+	}
+
+	return mt, nil
+}
+
+// Construction converts the tables into a Chow construction with identity input and output masks. It returns an error
+// if any table of either XOR network has internal encodings: Muir's XOR tables form a tree, while this package's Chow
+// construction XORs words in a chain, so only unencoded XOR tables can be translated.
+func (mt *MuirTables) Construction() (constr chow.Construction, err error) {
+	for round := range mt.XOR {
+		for i := range mt.XOR[round] {
+			for a := 0; a < 16; a++ {
+				for b := 0; b < 16; b++ {
+					if mt.XOR[round][i][a][b] != uint8(a^b) {
+						return constr, fmt.Errorf("XOR table %v of round %v has internal encodings", i, round)
+					}
+				}
+			}
+		}
+	}
+
+	for pos := 0; pos < 16; pos++ {
+		constr.InputMask[pos] = byteToBlock{nil, pos}
+		constr.TBoxOutputMask[pos] = byteToBlock{&mt.TBoxesLast[pos], pos}
+	}
+
+	for round := 0; round < 9; round++ {
+		for pos := 0; pos < 16; pos++ {
+			constr.TBoxTyiTable[round][pos] = wordTable{&mt.TyBoxes[round][pos]}
+			constr.MBInverseTable[round][pos] = wordTable{&mt.MBL[round][pos]}
+		}
+
+		for pos := 0; pos < 32; pos++ {
+			for gate := 0; gate < 3; gate++ {
+				constr.HighXORTable[round][pos][gate] = common.NibbleXORTable{}
+				constr.LowXORTable[round][pos][gate] = common.NibbleXORTable{}
+			}
+		}
+	}
+
+	for pos := 0; pos < 32; pos++ {
+		for gate := 0; gate < 15; gate++ {
+			constr.InputXORTables[pos][gate] = common.NibbleXORTable{}
+			constr.OutputXORTables[pos][gate] = common.NibbleXORTable{}
+		}
+	}
+
+	return constr, nil
+}
+
+// byteToBlock is a block table which puts the output of a byte table at one position of an otherwise zero block.
+type byteToBlock struct {
+	table    *[256]uint8 // nil for the identity.
+	position int
+}
+
+func (btb byteToBlock) Get(i byte) (out [16]byte) {
+	if btb.table == nil {
+		out[btb.position] = i
+	} else {
+		out[btb.position] = btb.table[i]
+	}
+
+	return
+}
+
+// wordTable is a word table which splits 32-bit integers into bytes, most significant first.
+type wordTable struct {
+	table *[256]uint32
+}
+
+func (wt wordTable) Get(i byte) (out [4]byte) {
+	binary.BigEndian.PutUint32(out[:], wt.table[i])
+	return
+}
@@ -0,0 +1,104 @@
+// Package harness differentially tests white-box constructions against crypto/aes.
+//
+// A construction is checked by pushing randomly chosen blocks through it, with its masks removed, and through the
+// standard library's AES with the same key. The first block on which the two disagree is reported as a Mismatch, which
+// carries everything needed to regenerate the construction and replay the failing block.
+package harness
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"fmt"
+	"math/rand"
+
+	"github.com/OpenWhiteBox/primitives/encoding"
+
+	"github.com/OpenWhiteBox/AES/constructions/common"
+	"github.com/OpenWhiteBox/AES/modes"
+)
+
+// Case is a construction under test. Key, Seed, and Opts are the arguments the construction was generated with; only Key
+// is needed to run the test, but all three are copied into any Mismatch so that it can be reproduced.
+type Case struct {
+	Constr                cipher.Block
+	InputMask, OutputMask encoding.Block
+
+	Key  []byte
+	Seed []byte
+	Opts common.KeyGenerationOpts // nil for constructions without options, like toy and full.
+
+	Decrypt bool // Whether to check decryption: Constr must be a decryption construction, or modes.Invertible.
+}
+
+// Mismatch is a block on which a construction disagrees with crypto/aes.
+type Mismatch struct {
+	Key, Seed []byte
+	Opts      common.KeyGenerationOpts
+	Decrypt   bool
+
+	RandSeed int64 // The seed of the generator the blocks were drawn from.
+	Trial    int   // The number of blocks tested before this one.
+
+	Input, Expected, Got []byte
+}
+
+// Error returns a description of the mismatch with all of its reproduction data.
+func (m *Mismatch) Error() string {
+	direction := "encryption"
+	if m.Decrypt {
+		direction = "decryption"
+	}
+
+	return fmt.Sprintf(
+		"%v mismatch on trial %v (rand seed %v): key=%x seed=%x opts=%q input=%x expected=%x got=%x",
+		direction, m.Trial, m.RandSeed, m.Key, m.Seed, common.DescribeOpts(m.Opts), m.Input, m.Expected, m.Got,
+	)
+}
+
+// Run pushes trials random blocks, drawn from a generator seeded with randSeed, through the construction in c and through
+// crypto/aes. It returns the first block they disagree on, or nil if they agree on every block. The first two blocks are
+// always all zeros and all ones.
+func Run(c Case, trials int, randSeed int64) (*Mismatch, error) {
+	real, err := aes.NewCipher(c.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	cand := modes.NewMaskedBlock(c.Constr, c.InputMask, c.OutputMask)
+	realCrypt, candCrypt := real.Encrypt, cand.Encrypt
+	if c.Decrypt {
+		realCrypt, candCrypt = real.Decrypt, cand.Decrypt
+	}
+
+	r := rand.New(rand.NewSource(randSeed))
+	in, expected, got := make([]byte, 16), make([]byte, 16), make([]byte, 16)
+
+	for trial := 0; trial < trials; trial++ {
+		switch trial {
+		case 0:
+		case 1:
+			for i := range in {
+				in[i] = 0xff
+			}
+		default:
+			r.Read(in)
+		}
+
+		realCrypt(expected, in)
+		candCrypt(got, in)
+
+		if !bytes.Equal(expected, got) {
+			return &Mismatch{
+				Key: c.Key, Seed: c.Seed, Opts: c.Opts, Decrypt: c.Decrypt,
+
+				RandSeed: randSeed,
+				Trial:    trial,
+
+				Input: in, Expected: expected, Got: got,
+			}, nil
+		}
+	}
+
+	return nil, nil
+}
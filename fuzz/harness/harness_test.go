@@ -0,0 +1,66 @@
+package harness
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/OpenWhiteBox/primitives/encoding"
+
+	"github.com/OpenWhiteBox/AES/constructions/chow"
+	"github.com/OpenWhiteBox/AES/constructions/common"
+	"github.com/OpenWhiteBox/AES/constructions/toy"
+)
+
+var (
+	key  = []byte{72, 101, 108, 108, 111, 32, 87, 111, 114, 108, 100, 33, 33, 33, 33, 33}
+	seed = []byte{38, 41, 142, 156, 29, 181, 23, 194, 21, 250, 223, 183, 210, 168, 214, 145}
+)
+
+func TestRun(t *testing.T) {
+	constr, inputMask, outputMask := toy.GenerateKeys(key, seed)
+
+	m, err := Run(Case{Constr: constr, InputMask: inputMask, OutputMask: outputMask, Key: key, Seed: seed}, 64, 1)
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	} else if m != nil {
+		t.Fatal(m)
+	}
+}
+
+func TestRunDecrypt(t *testing.T) {
+	opts := common.IndependentMasks{Input: common.RandomMask, Output: common.RandomMask}
+	constr, inputMask, outputMask := chow.GenerateDecryptionKeys(key, seed, opts)
+
+	m, err := Run(Case{
+		Constr:     constr,
+		InputMask:  encoding.NewBlockLinear(inputMask),
+		OutputMask: encoding.NewBlockLinear(outputMask),
+
+		Key: key, Seed: seed, Opts: opts,
+		Decrypt: true,
+	}, 64, 1)
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	} else if m != nil {
+		t.Fatal(m)
+	}
+}
+
+func TestMismatch(t *testing.T) {
+	constr, inputMask, _ := toy.GenerateKeys(key, seed)
+
+	// Pair the construction with the wrong output mask, as a keygen bug might.
+	c := Case{Constr: constr, InputMask: inputMask, OutputMask: inputMask, Key: key, Seed: seed}
+
+	m, err := Run(c, 64, 1)
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	} else if m == nil {
+		t.Fatal("Run didn't find a mismatch with the wrong output mask!")
+	}
+
+	// The same random seed should reproduce the same mismatch.
+	if replay, _ := Run(c, 64, 1); replay == nil || replay.Trial != m.Trial || !bytes.Equal(replay.Input, m.Input) {
+		t.Fatalf("Mismatch doesn't replay! %v != %v", replay, m)
+	}
+}
@@ -0,0 +1,89 @@
+// Package gf128 implements arithmetic in GF(2^128), the binary field of GHASH, modulo x^128 + x^7 + x^2 + x + 1.
+//
+// Elements use GCM's bit order: the most significant bit of the first byte of a block is the coefficient of x^0, and
+// the least significant bit of the last byte is the coefficient of x^127. Multiplication is carry-less multiplication
+// of 64-bit words in constant time, followed by a reduction; there is no hardware-specific path.
+package gf128
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// Elem is an element of GF(2^128). The zero value is the zero element.
+type Elem struct {
+	hi, lo uint64
+}
+
+// One is the multiplicative identity.
+var One = Elem{1 << 63, 0}
+
+// NewElem returns the field element encoded in the first 16 bytes of in.
+func NewElem(in []byte) Elem {
+	return Elem{binary.BigEndian.Uint64(in[0:8]), binary.BigEndian.Uint64(in[8:16])}
+}
+
+// Bytes returns the 16-byte encoding of the field element.
+func (e Elem) Bytes() []byte {
+	out := make([]byte, 16)
+	binary.BigEndian.PutUint64(out[0:8], e.hi)
+	binary.BigEndian.PutUint64(out[8:16], e.lo)
+
+	return out
+}
+
+// Add returns e + f.
+func (e Elem) Add(f Elem) Elem {
+	return Elem{e.hi ^ f.hi, e.lo ^ f.lo}
+}
+
+// Mul returns e * f.
+func (e Elem) Mul(f Elem) Elem {
+	// Reverse the bits so that bit i of each 128-bit value is the coefficient of x^i.
+	ahi, alo := bits.Reverse64(e.lo), bits.Reverse64(e.hi)
+	bhi, blo := bits.Reverse64(f.lo), bits.Reverse64(f.hi)
+
+	// Schoolbook multiplication into a 256-bit product, z3:z2:z1:z0.
+	p0hi, p0lo := clmul(alo, blo)
+	p1hi, p1lo := clmul(ahi, blo)
+	p2hi, p2lo := clmul(alo, bhi)
+	p3hi, p3lo := clmul(ahi, bhi)
+
+	z0, z1 := p0lo, p0hi^p1lo^p2lo
+	z2, z3 := p3lo^p1hi^p2hi, p3hi
+
+	// Reduce: x^128 = x^7 + x^2 + x + 1, so fold z3:z2 back in at each of those shifts. The bits shifted past x^127 are
+	// folded in a second time, which can't overflow again.
+	hi, lo, over := z1, z0, uint64(0)
+	for _, k := range []uint{0, 1, 2, 7} {
+		lo ^= z2 << k
+		hi ^= z3<<k | z2>>(64-k)
+		over ^= z3 >> (64 - k)
+	}
+	lo ^= over ^ over<<1 ^ over<<2 ^ over<<7
+
+	return Elem{bits.Reverse64(lo), bits.Reverse64(hi)}
+}
+
+// Invert returns the multiplicative inverse of e, computed as e^(2^128 - 2). The inverse of zero is zero.
+func (e Elem) Invert() Elem {
+	// 2^128 - 2 is 127 ones followed by a zero, in binary.
+	out := One
+	for i := 0; i < 127; i++ {
+		out = out.Mul(out).Mul(e)
+	}
+
+	return out.Mul(out)
+}
+
+// clmul returns the 128-bit carry-less product of a and b. It runs in constant time.
+func clmul(a, b uint64) (hi, lo uint64) {
+	for i := uint(0); i < 64; i++ {
+		mask := -(b >> i & 1)
+
+		lo ^= a << i & mask
+		hi ^= a >> (64 - i) & mask
+	}
+
+	return
+}
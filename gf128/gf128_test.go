@@ -0,0 +1,87 @@
+package gf128
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// slowMul multiplies two field elements one bit at a time, as in NIST SP 800-38D, Algorithm 1.
+func slowMul(e, f Elem) (out Elem) {
+	v := f
+
+	for i := 0; i < 128; i++ {
+		word := e.hi
+		if i >= 64 {
+			word = e.lo
+		}
+
+		if word>>uint(63-i%64)&1 == 1 {
+			out = out.Add(v)
+		}
+
+		carry := v.lo & 1
+		v.lo = v.lo>>1 | v.hi<<63
+		v.hi >>= 1
+		if carry == 1 {
+			v.hi ^= 0xe100000000000000
+		}
+	}
+
+	return
+}
+
+func randomElem(r *rand.Rand) Elem {
+	return Elem{r.Uint64(), r.Uint64()}
+}
+
+func TestMul(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+
+	for i := 0; i < 1000; i++ {
+		e, f := randomElem(r), randomElem(r)
+
+		if real, cand := slowMul(e, f), e.Mul(f); real != cand {
+			t.Fatalf("Mul(%x, %x) is wrong! %x != %x", e.Bytes(), f.Bytes(), real.Bytes(), cand.Bytes())
+		}
+	}
+
+	e := randomElem(r)
+	if e.Mul(One) != e || One.Mul(e) != e {
+		t.Fatal("One isn't the multiplicative identity!")
+	} else if e.Mul(Elem{}) != (Elem{}) {
+		t.Fatal("Multiplying by zero isn't zero!")
+	}
+}
+
+func TestInvert(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+
+	for i := 0; i < 100; i++ {
+		e := randomElem(r)
+
+		if e.Mul(e.Invert()) != One {
+			t.Fatalf("Invert(%x) is wrong! %x", e.Bytes(), e.Invert().Bytes())
+		}
+	}
+
+	if (Elem{}).Invert() != (Elem{}) {
+		t.Fatal("Inverse of zero isn't zero!")
+	}
+}
+
+func TestBytes(t *testing.T) {
+	in := []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+	if out := NewElem(in).Bytes(); string(in) != string(out) {
+		t.Fatalf("Bytes doesn't invert NewElem! %x != %x", in, out)
+	}
+}
+
+func BenchmarkMul(b *testing.B) {
+	r := rand.New(rand.NewSource(0))
+	e, f := randomElem(r), randomElem(r)
+
+	for i := 0; i < b.N; i++ {
+		e = e.Mul(f)
+	}
+}
@@ -0,0 +1,120 @@
+// Package generate generates white-box constructions of any type, along with their masks as affine encodings, for the
+// tools in this repository that hand constructions out.
+package generate
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/OpenWhiteBox/primitives/encoding"
+	"github.com/OpenWhiteBox/primitives/matrix"
+
+	"github.com/OpenWhiteBox/AES/constructions/chow"
+	"github.com/OpenWhiteBox/AES/constructions/common"
+	"github.com/OpenWhiteBox/AES/constructions/full"
+	"github.com/OpenWhiteBox/AES/constructions/toy"
+	"github.com/OpenWhiteBox/AES/constructions/xiao"
+)
+
+// MaskOpts are the named choices of masks for chow and xiao constructions. Toy and full constructions always have
+// random affine masks.
+var MaskOpts = map[string]common.KeyGenerationOpts{
+	"random":   common.IndependentMasks{Input: common.RandomMask, Output: common.RandomMask},
+	"identity": common.SameMasks(common.IdentityMask),
+	"matching": common.MatchingMasks{},
+}
+
+// Keys is a generated construction and its masks.
+type Keys struct {
+	Construction          common.Construction
+	InputMask, OutputMask encoding.BlockAffine
+
+	// Opts are the options the construction was generated with, or nil for constructions without options, like toy and
+	// full.
+	Opts common.KeyGenerationOpts
+}
+
+// ParseType returns the construction type with the given name, as in common.ConstructionType.String.
+func ParseType(name string) (common.ConstructionType, error) {
+	for _, constrType := range []common.ConstructionType{common.Chow, common.Xiao, common.Toy, common.Full} {
+		if constrType.String() == name {
+			return constrType, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown construction type %q", name)
+}
+
+// Construction generates an encryption construction of the given type from key and seed. opts is ignored for toy and
+// full constructions.
+func Construction(constrType common.ConstructionType, opts common.KeyGenerationOpts, key, seed []byte) (*Keys, error) {
+	switch constrType {
+	case common.Chow:
+		constr, in, out := chow.GenerateEncryptionKeys(key, seed, opts)
+		return &Keys{&constr, LinearMask(in), LinearMask(out), opts}, nil
+	case common.Xiao:
+		constr, in, out := xiao.GenerateEncryptionKeys(key, seed, opts)
+		return &Keys{&constr, LinearMask(in), LinearMask(out), opts}, nil
+	case common.Toy:
+		constr, in, out := toy.GenerateKeys(key, seed)
+		return &Keys{&constr, in, out, nil}, nil
+	case common.Full:
+		constr, in, out := full.GenerateKeys(key, seed)
+		return &Keys{&constr, in, out, nil}, nil
+	default:
+		return nil, fmt.Errorf("unknown construction type %v", constrType)
+	}
+}
+
+// Mask is a hex-encoded affine mask on a 128-bit block. Linear holds the rows of the matrix, where bit i of a row is bit
+// i%8 of byte i/8.
+type Mask struct {
+	Linear   []string `json:"linear"`
+	Constant string   `json:"constant"`
+}
+
+// NewMask hex-encodes an affine mask.
+func NewMask(ba encoding.BlockAffine) (out Mask) {
+	for _, row := range ba.Forwards {
+		out.Linear = append(out.Linear, hex.EncodeToString(row))
+	}
+	out.Constant = hex.EncodeToString(ba.BlockAdditive[:])
+
+	return
+}
+
+// BlockAffine decodes the mask. It returns an error if the mask is malformed or isn't invertible.
+func (m Mask) BlockAffine() (encoding.BlockAffine, error) {
+	linear, constant := matrix.Matrix{}, [16]byte{}
+
+	if len(m.Linear) != 128 {
+		return encoding.BlockAffine{}, errors.New("mask must have 128 rows")
+	}
+
+	for _, hexRow := range m.Linear {
+		row, err := hex.DecodeString(hexRow)
+		if err != nil || len(row) != 16 {
+			return encoding.BlockAffine{}, errors.New("mask has a malformed row")
+		}
+
+		linear = append(linear, matrix.Row(row))
+	}
+
+	if _, ok := linear.Invert(); !ok {
+		return encoding.BlockAffine{}, errors.New("mask isn't invertible")
+	}
+
+	c, err := hex.DecodeString(m.Constant)
+	if err != nil || len(c) != 16 {
+		return encoding.BlockAffine{}, errors.New("mask has a malformed constant")
+	}
+	copy(constant[:], c)
+
+	return encoding.NewBlockAffine(linear, constant), nil
+}
+
+// LinearMask turns the linear mask of a chow or xiao construction into an affine mask with a zero constant.
+func LinearMask(m matrix.Matrix) encoding.BlockAffine {
+	return encoding.BlockAffine{BlockLinear: encoding.NewBlockLinear(m)}
+}
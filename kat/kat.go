@@ -0,0 +1,153 @@
+// Package kat generates known-answer test files for white-box constructions.
+//
+// A KAT file is a JSON document with everything an independent implementation needs to check that it generates or
+// evaluates a construction the same way this repository does: the AES key and generation seed, the external masks, a
+// digest of every serialized section of the construction, and a list of test vectors. Generation is deterministic--the
+// same type, options, key, and seed always give the same file.
+package kat
+
+import (
+	"crypto/aes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/OpenWhiteBox/AES/constructions/common"
+	"github.com/OpenWhiteBox/AES/internal/generate"
+)
+
+// KAT is a known-answer test file. All byte strings are hex-encoded.
+type KAT struct {
+	Construction string `json:"construction"`
+	Options      string `json:"options,omitempty"`
+
+	Key  string `json:"key"`
+	Seed string `json:"seed"`
+
+	InputMask  Mask `json:"inputMask"`
+	OutputMask Mask `json:"outputMask"`
+
+	Digests Digests  `json:"digests"`
+	Vectors []Vector `json:"vectors"`
+}
+
+// Mask is an affine mask on a 128-bit block. Linear holds the rows of the matrix, where bit i of a row is bit i%8 of
+// byte i/8. Linear and Constant describe the mask's forward (Encode) map: M(x) = Linear*x XOR Constant.
+type Mask = generate.Mask
+
+// Digests are SHA-256 digests of a serialized construction, as a whole and of each section of its container.
+type Digests struct {
+	Serialized string   `json:"serialized"`
+	Sections   []string `json:"sections"`
+}
+
+// Vector is one test vector. Plaintext and Ciphertext are unmasked AES. Input and Output are what the construction
+// actually computes on. Writing each mask for its forward map, and ^-1 for the inverse:
+//
+//	Input = InputMask^-1(Plaintext)
+//	Output = OutputMask(Ciphertext), so Ciphertext = OutputMask^-1(Output)
+//
+// An implementation checking a vector from the JSON alone has to invert the input mask, not apply it.
+type Vector struct {
+	Plaintext  string `json:"plaintext"`
+	Ciphertext string `json:"ciphertext"`
+	Input      string `json:"input"`
+	Output     string `json:"output"`
+}
+
+// Generate generates a construction of the given type from key and seed, and returns a KAT file for it with n test
+// vectors. opts is ignored for toy and full constructions. It returns an error if the construction disagrees with
+// crypto/aes on any vector.
+func Generate(constrType common.ConstructionType, opts common.KeyGenerationOpts, key, seed []byte, n int) (*KAT, error) {
+	generated, err := generate.Construction(constrType, opts, key, seed)
+	if err != nil {
+		return nil, err
+	}
+	constr, inputMask, outputMask := generated.Construction, generated.InputMask, generated.OutputMask
+
+	real, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	serialized := constr.Serialize()
+	digests, err := newDigests(serialized)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &KAT{
+		Construction: constrType.String(),
+		Options:      common.DescribeOpts(generated.Opts),
+
+		Key:  hex.EncodeToString(key),
+		Seed: hex.EncodeToString(seed),
+
+		InputMask:  generate.NewMask(inputMask),
+		OutputMask: generate.NewMask(outputMask),
+
+		Digests: digests,
+		Vectors: make([]Vector, n),
+	}
+
+	for i := range out.Vectors {
+		pt, ct, masked := plaintext(seed, i), [16]byte{}, [16]byte{}
+		real.Encrypt(ct[:], pt[:])
+
+		in := inputMask.Decode(pt)
+		constr.Encrypt(masked[:], in[:])
+
+		if outputMask.Decode(masked) != ct {
+			return nil, fmt.Errorf("construction disagrees with crypto/aes on vector %v", i)
+		}
+
+		out.Vectors[i] = Vector{
+			Plaintext:  hex.EncodeToString(pt[:]),
+			Ciphertext: hex.EncodeToString(ct[:]),
+			Input:      hex.EncodeToString(in[:]),
+			Output:     hex.EncodeToString(masked[:]),
+		}
+	}
+
+	return out, nil
+}
+
+// Marshal encodes the KAT file as indented JSON.
+func (k *KAT) Marshal() ([]byte, error) {
+	return json.MarshalIndent(k, "", "  ")
+}
+
+// plaintext returns the i-th plaintext of a KAT file generated with seed: the first 16 bytes of
+// SHA-256(seed || uint32(i)), with i big-endian.
+func plaintext(seed []byte, i int) (out [16]byte) {
+	h := sha256.New()
+	h.Write(seed)
+	binary.Write(h, binary.BigEndian, uint32(i))
+
+	copy(out[:], h.Sum(nil))
+	return
+}
+
+func newDigests(serialized []byte) (Digests, error) {
+	constrType, sizes, err := common.Header(serialized)
+	if err != nil {
+		return Digests{}, err
+	}
+
+	sections, err := common.Open(constrType, serialized, sizes...)
+	if err != nil {
+		return Digests{}, err
+	}
+
+	sum := sha256.Sum256(serialized)
+	out := Digests{Serialized: hex.EncodeToString(sum[:])}
+
+	for _, section := range sections {
+		sum := sha256.Sum256(section)
+		out.Sections = append(out.Sections, hex.EncodeToString(sum[:]))
+	}
+
+	return out, nil
+}
@@ -0,0 +1,46 @@
+package kat
+
+import (
+	"bytes"
+	"crypto/aes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/OpenWhiteBox/AES/constructions/common"
+)
+
+var (
+	key  = []byte{72, 101, 108, 108, 111, 32, 87, 111, 114, 108, 100, 33, 33, 33, 33, 33}
+	seed = []byte{38, 41, 142, 156, 29, 181, 23, 194, 21, 250, 223, 183, 210, 168, 214, 145}
+)
+
+func TestGenerate(t *testing.T) {
+	kat1, err := Generate(common.Toy, nil, key, seed, 8)
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+
+	c, _ := aes.NewCipher(key)
+	for _, vect := range kat1.Vectors {
+		pt, _ := hex.DecodeString(vect.Plaintext)
+		real, cand := make([]byte, 16), vect.Ciphertext
+
+		c.Encrypt(real, pt)
+		if hex.EncodeToString(real) != cand {
+			t.Fatalf("Real disagrees with result! %x != %v", real, cand)
+		}
+	}
+
+	kat2, err := Generate(common.Toy, nil, key, seed, 8)
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+
+	out1, _ := kat1.Marshal()
+	out2, _ := kat2.Marshal()
+	if !bytes.Equal(out1, out2) {
+		t.Fatal("Generate isn't deterministic!")
+	} else if len(kat1.Digests.Sections) != 11 {
+		t.Fatalf("KAT has %v section digests, expected 11", len(kat1.Digests.Sections))
+	}
+}
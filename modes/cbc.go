@@ -0,0 +1,81 @@
+package modes
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+
+	"github.com/OpenWhiteBox/primitives/encoding"
+)
+
+// EncryptCBC encrypts plaintext with AES in CBC mode with the white-box construction constr. inputMask and outputMask
+// are the masks constr was generated with; they're removed internally. The plaintext is padded with PKCS#7 and a random
+// IV is prepended to the output.
+func EncryptCBC(constr cipher.Block, inputMask, outputMask encoding.Block, plaintext []byte) ([]byte, error) {
+	block := NewMaskedBlock(constr, inputMask, outputMask)
+	padded := pad(plaintext, block.BlockSize())
+
+	out := make([]byte, block.BlockSize()+len(padded))
+	iv := out[:block.BlockSize()]
+	if _, err := rand.Read(iv); err != nil {
+		return nil, err
+	}
+
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(out[block.BlockSize():], padded)
+
+	return out, nil
+}
+
+// DecryptCBC decrypts the output of EncryptCBC with the white-box construction constr, which should be a decryption
+// construction or an Invertible one. inputMask and outputMask are the masks constr was generated with. It returns an
+// error if ciphertext is malformed or its padding is invalid.
+func DecryptCBC(constr cipher.Block, inputMask, outputMask encoding.Block, ciphertext []byte) ([]byte, error) {
+	block := NewMaskedBlock(constr, inputMask, outputMask)
+	size := block.BlockSize()
+
+	if len(ciphertext) < 2*size {
+		return nil, errors.New("ciphertext is too short")
+	} else if len(ciphertext)%size != 0 {
+		return nil, errors.New("ciphertext is not a multiple of the block size")
+	}
+
+	iv, ciphertext := ciphertext[:size], ciphertext[size:]
+
+	out := make([]byte, len(ciphertext))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(out, ciphertext)
+
+	return unpad(out, size)
+}
+
+// pad appends PKCS#7 padding to in, so that its length is a multiple of size.
+func pad(in []byte, size int) []byte {
+	n := size - len(in)%size
+
+	out := make([]byte, len(in)+n)
+	copy(out, in)
+	for i := len(in); i < len(out); i++ {
+		out[i] = byte(n)
+	}
+
+	return out
+}
+
+// unpad removes PKCS#7 padding from in. It returns an error if the padding is invalid.
+func unpad(in []byte, size int) ([]byte, error) {
+	if len(in) == 0 || len(in)%size != 0 {
+		return nil, errors.New("invalid padding")
+	}
+
+	n := int(in[len(in)-1])
+	if n == 0 || n > size {
+		return nil, errors.New("invalid padding")
+	}
+
+	for _, b := range in[len(in)-n:] {
+		if int(b) != n {
+			return nil, errors.New("invalid padding")
+		}
+	}
+
+	return in[:len(in)-n], nil
+}
@@ -0,0 +1,90 @@
+package modes
+
+import (
+	"crypto/cipher"
+	"hash"
+
+	"github.com/OpenWhiteBox/primitives/encoding"
+)
+
+// cmac implements hash.Hash with AES-CMAC, as specified in RFC 4493.
+type cmac struct {
+	block  cipher.Block
+	k1, k2 []byte
+
+	state   []byte // The running CBC-MAC state.
+	pending []byte // Input which hasn't been absorbed into state yet. The last block is only absorbed in Sum.
+}
+
+// NewCMAC returns a hash.Hash which computes AES-CMAC with the white-box construction constr. inputMask and outputMask
+// are the masks constr was generated with; they're removed internally.
+//
+// CMAC only uses the forwards direction of the block cipher, so constr should be an encryption construction.
+func NewCMAC(constr cipher.Block, inputMask, outputMask encoding.Block) hash.Hash {
+	block := NewMaskedBlock(constr, inputMask, outputMask)
+
+	l := make([]byte, 16)
+	block.Encrypt(l, l)
+
+	k1 := double(l)
+	k2 := double(k1)
+
+	return &cmac{block: block, k1: k1, k2: k2, state: make([]byte, 16)}
+}
+
+// double multiplies in by x in GF(2^128), as defined for CMAC subkey generation.
+func double(in []byte) []byte {
+	out := make([]byte, 16)
+
+	for i := 0; i < 15; i++ {
+		out[i] = in[i]<<1 | in[i+1]>>7
+	}
+	out[15] = in[15] << 1
+
+	if in[0]&0x80 != 0 {
+		out[15] ^= 0x87
+	}
+
+	return out
+}
+
+func (c *cmac) Size() int { return 16 }
+
+func (c *cmac) BlockSize() int { return 16 }
+
+func (c *cmac) Reset() {
+	c.state, c.pending = make([]byte, 16), nil
+}
+
+func (c *cmac) Write(p []byte) (int, error) {
+	c.pending = append(c.pending, p...)
+
+	// Absorb every full block except the last one, which might need to be combined with a subkey.
+	for len(c.pending) > 16 {
+		encoding.XOR(c.state, c.state, c.pending[:16])
+		c.block.Encrypt(c.state, c.state)
+
+		c.pending = c.pending[16:]
+	}
+
+	return len(p), nil
+}
+
+// Sum appends the MAC of everything written so far to in. It doesn't change the underlying state.
+func (c *cmac) Sum(in []byte) []byte {
+	last := make([]byte, 16)
+	copy(last, c.pending)
+
+	if len(c.pending) == 16 {
+		encoding.XOR(last, last, c.k1)
+	} else {
+		last[len(c.pending)] = 0x80
+		encoding.XOR(last, last, c.k2)
+	}
+
+	out := make([]byte, 16)
+	encoding.XOR(out, c.state, last)
+	c.block.Encrypt(out, out)
+
+	return append(in, out...)
+}
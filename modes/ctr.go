@@ -0,0 +1,16 @@
+package modes
+
+import (
+	"crypto/cipher"
+
+	"github.com/OpenWhiteBox/primitives/encoding"
+)
+
+// NewCTR returns a cipher.Stream which computes AES in counter mode with the white-box construction constr.
+// inputMask and outputMask are the masks constr was generated with; they're removed internally. The length of iv must be
+// 16 bytes. Like cipher.NewCTR, it panics otherwise.
+//
+// Counter mode only uses the forwards direction of the block cipher, so constr should be an encryption construction.
+func NewCTR(constr cipher.Block, inputMask, outputMask encoding.Block, iv []byte) cipher.Stream {
+	return cipher.NewCTR(NewMaskedBlock(constr, inputMask, outputMask), iv)
+}
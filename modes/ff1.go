@@ -0,0 +1,158 @@
+package modes
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"strings"
+
+	"github.com/OpenWhiteBox/primitives/encoding"
+)
+
+// ff1Digits are the numerals of every radix FF1 supports, in order.
+const ff1Digits = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+// FF1 implements NIST SP 800-38G FF1 format-preserving encryption over a white-box construction. Inputs and outputs are
+// strings of numerals in the given radix, written with the digits 0-9 and then the lowercase letters a-z.
+type FF1 struct {
+	block cipher.Block
+	radix int
+}
+
+// NewFF1 returns an FF1 cipher for strings in the given radix, which must be between 2 and 36. inputMask and outputMask
+// are the masks constr was generated with; they're removed internally.
+//
+// FF1 only uses the forwards direction of the block cipher, so constr should be an encryption construction.
+func NewFF1(constr cipher.Block, inputMask, outputMask encoding.Block, radix int) (*FF1, error) {
+	if radix < 2 || radix > len(ff1Digits) {
+		return nil, errors.New("radix must be between 2 and 36")
+	}
+
+	return &FF1{block: NewMaskedBlock(constr, inputMask, outputMask), radix: radix}, nil
+}
+
+// Encrypt encrypts the numeral string x under the given tweak. The output has the same length and radix as x.
+func (ff *FF1) Encrypt(tweak []byte, x string) (string, error) {
+	return ff.crypt(tweak, x, true)
+}
+
+// Decrypt decrypts the numeral string x under the given tweak.
+func (ff *FF1) Decrypt(tweak []byte, x string) (string, error) {
+	return ff.crypt(tweak, x, false)
+}
+
+// crypt runs the ten Feistel rounds of FF1 in the given direction.
+func (ff *FF1) crypt(tweak []byte, x string, encrypt bool) (string, error) {
+	n := len(x)
+	u, v := n/2, n-n/2
+
+	// The domain must have at least a million elements, per SP 800-38G.
+	radix := big.NewInt(int64(ff.radix))
+	if new(big.Int).Exp(radix, big.NewInt(int64(n)), nil).Cmp(big.NewInt(1000000)) < 0 {
+		return "", errors.New("input is too short for its radix")
+	} else if strings.TrimLeft(x, ff1Digits[:ff.radix]) != "" {
+		return "", errors.New("input contains a numeral outside of its radix")
+	}
+
+	modU := new(big.Int).Exp(radix, big.NewInt(int64(u)), nil)
+	modV := new(big.Int).Exp(radix, big.NewInt(int64(v)), nil)
+
+	// b is the number of bytes needed to hold any v-numeral string, and d the number of pseudorandom bytes per round.
+	b := len(new(big.Int).Sub(modV, big.NewInt(1)).Bytes())
+	d := 4*((b+3)/4) + 4
+
+	p := []byte{1, 2, 1, 0, 0, 0, 10, byte(u), 0, 0, 0, 0, 0, 0, 0, 0}
+	p[3], p[4], p[5] = byte(ff.radix>>16), byte(ff.radix>>8), byte(ff.radix)
+	binary.BigEndian.PutUint32(p[8:12], uint32(n))
+	binary.BigEndian.PutUint32(p[12:16], uint32(len(tweak)))
+
+	// Q is the tweak, zero padding, the round number, and then b bytes of the other half.
+	q := make([]byte, len(tweak)+(16-(len(tweak)+b+1)%16)%16+1+b)
+	copy(q, tweak)
+
+	a, bb := ff.num(x[:u]), ff.num(x[u:])
+	if !encrypt {
+		a, bb = bb, a
+	}
+
+	for i := 0; i < 10; i++ {
+		round, m := i, modU
+		if !encrypt {
+			round = 9 - i
+		}
+		if round%2 == 1 {
+			m = modV
+		}
+
+		for j := len(q) - b; j < len(q); j++ {
+			q[j] = 0
+		}
+		q[len(q)-b-1] = byte(round)
+		numB := bb.Bytes()
+		copy(q[len(q)-len(numB):], numB)
+
+		y := new(big.Int).SetBytes(ff.expand(ff.prf(p, q), d))
+
+		c := new(big.Int)
+		if encrypt {
+			c.Add(a, y)
+		} else {
+			c.Sub(a, y)
+		}
+		c.Mod(c, m)
+
+		a, bb = bb, c
+	}
+
+	if !encrypt {
+		a, bb = bb, a
+	}
+
+	return ff.str(a, u) + ff.str(bb, v), nil
+}
+
+// prf computes the CBC-MAC of p || q with a zero IV.
+func (ff *FF1) prf(p, q []byte) []byte {
+	out := make([]byte, 16)
+
+	for _, in := range [][]byte{p, q} {
+		for len(in) > 0 {
+			encoding.XOR(out, out, in[:16])
+			ff.block.Encrypt(out, out)
+			in = in[16:]
+		}
+	}
+
+	return out
+}
+
+// expand stretches the output of prf to d bytes by encrypting it XORed with successive counters.
+func (ff *FF1) expand(r []byte, d int) []byte {
+	out := append([]byte{}, r...)
+	block := make([]byte, 16)
+
+	for j := uint64(1); len(out) < d; j++ {
+		copy(block, r)
+		for k := 0; k < 8; k++ {
+			block[15-k] ^= byte(j >> uint(8*k))
+		}
+
+		ff.block.Encrypt(block, block)
+		out = append(out, block...)
+	}
+
+	return out[:d]
+}
+
+// num parses a numeral string in ff's radix.
+func (ff *FF1) num(x string) *big.Int {
+	out, _ := new(big.Int).SetString(x, ff.radix)
+	return out
+}
+
+// str writes x as a numeral string of exactly m numerals in ff's radix.
+func (ff *FF1) str(x *big.Int, m int) string {
+	out := x.Text(ff.radix)
+	return strings.Repeat("0", m-len(out)) + out
+}
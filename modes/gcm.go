@@ -0,0 +1,140 @@
+package modes
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+
+	"github.com/OpenWhiteBox/primitives/encoding"
+
+	"github.com/OpenWhiteBox/AES/gf128"
+)
+
+const (
+	gcmNonceSize = 12
+	gcmTagSize   = 16
+)
+
+// gcm implements cipher.AEAD with AES-GCM over a white-box construction.
+type gcm struct {
+	block cipher.Block
+	h     gf128.Elem
+}
+
+// NewGCM returns a cipher.AEAD which computes AES-GCM with the white-box construction constr. inputMask and outputMask
+// are the masks constr was generated with; they're removed internally. Nonces are 12 bytes and tags are 16 bytes.
+//
+// GCM only uses the forwards direction of the block cipher, so constr should be an encryption construction.
+func NewGCM(constr cipher.Block, inputMask, outputMask encoding.Block) cipher.AEAD {
+	block := NewMaskedBlock(constr, inputMask, outputMask)
+
+	h := make([]byte, 16)
+	block.Encrypt(h, h)
+
+	return &gcm{block: block, h: gf128.NewElem(h)}
+}
+
+func (g *gcm) NonceSize() int { return gcmNonceSize }
+
+func (g *gcm) Overhead() int { return gcmTagSize }
+
+// Seal encrypts and authenticates plaintext and additionalData, and appends the result to dst.
+func (g *gcm) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	if len(nonce) != gcmNonceSize {
+		panic("modes: incorrect nonce length given to GCM")
+	}
+
+	counter := g.counter(nonce)
+
+	out := make([]byte, len(plaintext)+gcmTagSize)
+	g.ctr(counter, out[:len(plaintext)], plaintext)
+	copy(out[len(plaintext):], g.tag(counter, out[:len(plaintext)], additionalData))
+
+	return append(dst, out...)
+}
+
+// Open authenticates and decrypts ciphertext and additionalData, and appends the plaintext to dst. It returns an error
+// if authentication fails.
+func (g *gcm) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != gcmNonceSize {
+		panic("modes: incorrect nonce length given to GCM")
+	} else if len(ciphertext) < gcmTagSize {
+		return nil, errors.New("message authentication failed")
+	}
+
+	counter := g.counter(nonce)
+
+	ciphertext, tag := ciphertext[:len(ciphertext)-gcmTagSize], ciphertext[len(ciphertext)-gcmTagSize:]
+	if subtle.ConstantTimeCompare(tag, g.tag(counter, ciphertext, additionalData)) != 1 {
+		return nil, errors.New("message authentication failed")
+	}
+
+	out := make([]byte, len(ciphertext))
+	g.ctr(counter, out, ciphertext)
+
+	return append(dst, out...), nil
+}
+
+// counter returns the pre-counter block J0 for a 12-byte nonce.
+func (g *gcm) counter(nonce []byte) []byte {
+	out := make([]byte, 16)
+	copy(out, nonce)
+	out[15] = 1
+
+	return out
+}
+
+// ctr encrypts src into dst in counter mode, starting one after the pre-counter block and incrementing the last 32 bits.
+func (g *gcm) ctr(counter, dst, src []byte) {
+	ctr, stream := make([]byte, 16), make([]byte, 16)
+	copy(ctr, counter)
+
+	for len(src) > 0 {
+		binary.BigEndian.PutUint32(ctr[12:], binary.BigEndian.Uint32(ctr[12:])+1)
+		g.block.Encrypt(stream, ctr)
+
+		n := len(src)
+		if n > 16 {
+			n = 16
+		}
+		for i := 0; i < n; i++ {
+			dst[i] = src[i] ^ stream[i]
+		}
+
+		dst, src = dst[n:], src[n:]
+	}
+}
+
+// tag computes the authentication tag of ciphertext and additionalData.
+func (g *gcm) tag(counter, ciphertext, additionalData []byte) []byte {
+	s := g.ghash(gf128.Elem{}, additionalData)
+	s = g.ghash(s, ciphertext)
+
+	lengths := make([]byte, 16)
+	binary.BigEndian.PutUint64(lengths[0:8], 8*uint64(len(additionalData)))
+	binary.BigEndian.PutUint64(lengths[8:16], 8*uint64(len(ciphertext)))
+	s = g.ghash(s, lengths)
+
+	out := make([]byte, 16)
+	g.block.Encrypt(out, counter)
+
+	return gf128.NewElem(out).Add(s).Bytes()
+}
+
+// ghash absorbs in into the running GHASH state, zero-padding the last block.
+func (g *gcm) ghash(state gf128.Elem, in []byte) gf128.Elem {
+	block := make([]byte, 16)
+
+	for len(in) > 0 {
+		n := copy(block, in)
+		for i := n; i < 16; i++ {
+			block[i] = 0x00
+		}
+
+		state = state.Add(gf128.NewElem(block)).Mul(g.h)
+		in = in[n:]
+	}
+
+	return state
+}
@@ -0,0 +1,82 @@
+package modes
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+
+	"github.com/OpenWhiteBox/primitives/encoding"
+)
+
+// keyWrapIV is the default initial value from RFC 3394, section 2.2.3.1.
+var keyWrapIV = []byte{0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6}
+
+// KeyWrap wraps key with the RFC 3394 key wrap algorithm, using the white-box construction constr as the key-encryption
+// key. constr should be an encryption construction and inputMask and outputMask are the masks it was generated with. The
+// length of key must be a multiple of 8 bytes and at least 16 bytes.
+func KeyWrap(constr cipher.Block, inputMask, outputMask encoding.Block, key []byte) ([]byte, error) {
+	if len(key) < 16 || len(key)%8 != 0 {
+		return nil, errors.New("key to wrap must be a multiple of 8 bytes and at least 16 bytes")
+	}
+
+	block := NewMaskedBlock(constr, inputMask, outputMask)
+	n := len(key) / 8
+
+	out := make([]byte, 8+len(key))
+	copy(out, keyWrapIV)
+	copy(out[8:], key)
+
+	a, b := out[:8], make([]byte, 16)
+
+	for j := 0; j < 6; j++ {
+		for i := 1; i <= n; i++ {
+			r := out[8*i : 8*(i+1)]
+
+			copy(b[:8], a)
+			copy(b[8:], r)
+			block.Encrypt(b, b)
+
+			binary.BigEndian.PutUint64(a, binary.BigEndian.Uint64(b[:8])^uint64(n*j+i))
+			copy(r, b[8:])
+		}
+	}
+
+	return out, nil
+}
+
+// KeyUnwrap unwraps the output of KeyWrap, using the white-box construction constr as the key-encryption key. constr
+// should be a decryption construction or an Invertible one, and inputMask and outputMask are the masks it was generated
+// with. It returns an error if wrapped is malformed or fails the integrity check.
+func KeyUnwrap(constr cipher.Block, inputMask, outputMask encoding.Block, wrapped []byte) ([]byte, error) {
+	if len(wrapped) < 24 || len(wrapped)%8 != 0 {
+		return nil, errors.New("wrapped key must be a multiple of 8 bytes and at least 24 bytes")
+	}
+
+	block := NewMaskedBlock(constr, inputMask, outputMask)
+	n := len(wrapped)/8 - 1
+
+	out := make([]byte, len(wrapped))
+	copy(out, wrapped)
+
+	a, b := out[:8], make([]byte, 16)
+
+	for j := 5; j >= 0; j-- {
+		for i := n; i >= 1; i-- {
+			r := out[8*i : 8*(i+1)]
+
+			binary.BigEndian.PutUint64(b[:8], binary.BigEndian.Uint64(a)^uint64(n*j+i))
+			copy(b[8:], r)
+			block.Decrypt(b, b)
+
+			copy(a, b[:8])
+			copy(r, b[8:])
+		}
+	}
+
+	if subtle.ConstantTimeCompare(a, keyWrapIV) != 1 {
+		return nil, errors.New("wrapped key failed integrity check")
+	}
+
+	return out[8:], nil
+}
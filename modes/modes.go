@@ -0,0 +1,98 @@
+// Package modes implements modes of operation over white-box AES constructions.
+//
+// Every construction in this repository computes a masked version of AES: when it's generated, it returns an input mask
+// and an output mask, and what it actually computes is outputMask(AES(inputMask(x))). The helpers here take a
+// construction and its masks and remove the masks internally, so that callers get plain AES in the given mode of
+// operation.
+//
+// Masks from constructions that return a matrix.Matrix (chow, xiao) can be passed as encoding.NewBlockLinear(mask).
+// Masks from constructions that return an encoding.BlockAffine (toy, full) can be passed as-is. NewMaskedBlock exposes
+// the unmasked block cipher directly, for modes not implemented here.
+//
+// Chow and Xiao-Lai constructions only compute one direction of AES, so modes which decrypt need a decryption
+// construction and its masks. Toy constructions compute both directions under the same masks and implement Invertible,
+// so one construction serves for both. Full constructions can only encrypt.
+package modes
+
+import (
+	"crypto/cipher"
+
+	"github.com/OpenWhiteBox/primitives/encoding"
+	"github.com/OpenWhiteBox/primitives/matrix"
+
+	"github.com/OpenWhiteBox/AES/constructions/common"
+)
+
+// Invertible is implemented by constructions whose Decrypt inverts Encrypt under the same masks, like those generated
+// by the toy package.
+type Invertible interface {
+	cipher.Block
+	Invertible()
+}
+
+// maskedBlock is a cipher.Block which removes the external encodings of a white-box construction, so that it computes
+// standard AES.
+type maskedBlock struct {
+	constr                cipher.Block
+	inputMask, outputMask encoding.Block
+	invertible            bool
+}
+
+// NewMaskedBlock returns a cipher.Block which computes standard AES with the white-box construction constr. inputMask
+// and outputMask are the masks constr was generated with: Encrypt applies the input mask before calling constr and
+// removes the output mask after. Decrypt does the same, unless constr is Invertible, in which case it applies the
+// output mask before calling constr and removes the input mask after.
+func NewMaskedBlock(constr cipher.Block, inputMask, outputMask encoding.Block) cipher.Block {
+	_, invertible := constr.(Invertible)
+	return maskedBlock{constr, inputMask, outputMask, invertible}
+}
+
+// NewLinearMaskedBlock is NewMaskedBlock for constructions whose masks are linear, like those generated by the chow and
+// xiao packages.
+func NewLinearMaskedBlock(constr cipher.Block, inputMask, outputMask matrix.Matrix) cipher.Block {
+	return NewMaskedBlock(constr, encoding.NewBlockLinear(inputMask), encoding.NewBlockLinear(outputMask))
+}
+
+// NewBoundMaskedBlock is NewLinearMaskedBlock for constructions generated with common.DeviceBinding. inputMask and
+// outputMask are the unbound masks, and fingerprint is the fingerprint of the device it's running on. If the
+// fingerprint isn't the one the construction was bound to, the returned block computes garbage.
+func NewBoundMaskedBlock(constr cipher.Block, inputMask, outputMask matrix.Matrix, fingerprint []byte) cipher.Block {
+	inputMask, outputMask = common.BindMasks(inputMask, outputMask, fingerprint)
+	return NewLinearMaskedBlock(constr, inputMask, outputMask)
+}
+
+// BlockSize returns the block size of AES. (Necessary to implement cipher.Block.)
+func (mb maskedBlock) BlockSize() int { return 16 }
+
+// Encrypt encrypts the first block in src into dst. Dst and src may point at the same memory.
+func (mb maskedBlock) Encrypt(dst, src []byte) {
+	mb.crypt(dst, src, false)
+}
+
+// Decrypt decrypts the first block in src into dst. Dst and src may point at the same memory.
+func (mb maskedBlock) Decrypt(dst, src []byte) {
+	mb.crypt(dst, src, true)
+}
+
+// crypt applies the input mask to src, pushes it through the construction, and removes the output mask. If decrypt is
+// true and the construction is invertible, the masks are swapped and applied in the other direction.
+func (mb maskedBlock) crypt(dst, src []byte, decrypt bool) {
+	temp := [16]byte{}
+	copy(temp[:], src[:16])
+
+	if !decrypt {
+		temp = mb.inputMask.Decode(temp) // Apply input encoding.
+		mb.constr.Encrypt(temp[:], temp[:])
+		temp = mb.outputMask.Decode(temp) // Remove output encoding.
+	} else if !mb.invertible {
+		temp = mb.inputMask.Decode(temp)
+		mb.constr.Decrypt(temp[:], temp[:])
+		temp = mb.outputMask.Decode(temp)
+	} else {
+		temp = mb.outputMask.Encode(temp) // Apply output encoding.
+		mb.constr.Decrypt(temp[:], temp[:])
+		temp = mb.inputMask.Encode(temp) // Remove input encoding.
+	}
+
+	copy(dst[:16], temp[:])
+}
@@ -0,0 +1,380 @@
+package modes
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/hex"
+	"io/ioutil"
+	"testing"
+
+	"github.com/OpenWhiteBox/primitives/encoding"
+
+	"github.com/OpenWhiteBox/AES/constructions/chow"
+	"github.com/OpenWhiteBox/AES/constructions/common"
+	"github.com/OpenWhiteBox/AES/constructions/toy"
+)
+
+var (
+	key   = []byte{72, 101, 108, 108, 111, 32, 87, 111, 114, 108, 100, 33, 33, 33, 33, 33}
+	seed  = []byte{38, 41, 142, 156, 29, 181, 23, 194, 21, 250, 223, 183, 210, 168, 214, 145}
+	iv    = []byte{99, 83, 224, 140, 9, 96, 225, 4, 205, 112, 183, 81, 186, 202, 208, 231}
+	input = []byte("White-box AES modes of operation should agree with crypto/aes.")
+)
+
+// encryptionKeys returns a masked Chow encryption construction for key, along with its masks as block encodings.
+func encryptionKeys(key []byte) (cipher.Block, encoding.Block, encoding.Block) {
+	opts := common.IndependentMasks{common.RandomMask, common.RandomMask}
+	constr, inputMask, outputMask := chow.GenerateEncryptionKeys(key, seed, opts)
+
+	return constr, encoding.NewBlockLinear(inputMask), encoding.NewBlockLinear(outputMask)
+}
+
+func TestCTR(t *testing.T) {
+	cand, real := make([]byte, len(input)), make([]byte, len(input))
+
+	// Calculate the candidate output.
+	constr, inputMask, outputMask := encryptionKeys(key)
+	NewCTR(constr, inputMask, outputMask, iv).XORKeyStream(cand, input)
+
+	// Calculate the real output.
+	c, _ := aes.NewCipher(key)
+	cipher.NewCTR(c, iv).XORKeyStream(real, input)
+
+	if !bytes.Equal(real, cand) {
+		t.Fatalf("Real disagrees with result! %x != %x", real, cand)
+	}
+}
+
+// decryptionKeys returns a masked Chow decryption construction for key, along with its masks as block encodings.
+func decryptionKeys(key []byte) (cipher.Block, encoding.Block, encoding.Block) {
+	opts := common.IndependentMasks{common.RandomMask, common.RandomMask}
+	constr, inputMask, outputMask := chow.GenerateDecryptionKeys(key, seed, opts)
+
+	return constr, encoding.NewBlockLinear(inputMask), encoding.NewBlockLinear(outputMask)
+}
+
+func TestEncryptCBC(t *testing.T) {
+	constr, inputMask, outputMask := encryptionKeys(key)
+
+	ciphertext, err := EncryptCBC(constr, inputMask, outputMask, input)
+	if err != nil {
+		t.Fatalf("EncryptCBC returned error: %v", err)
+	}
+
+	// Decrypt with crypto/aes and strip the padding by hand.
+	c, _ := aes.NewCipher(key)
+	cand := make([]byte, len(ciphertext)-16)
+	cipher.NewCBCDecrypter(c, ciphertext[:16]).CryptBlocks(cand, ciphertext[16:])
+	cand = cand[:len(cand)-int(cand[len(cand)-1])]
+
+	if !bytes.Equal(input, cand) {
+		t.Fatalf("Real disagrees with result! %x != %x", input, cand)
+	}
+}
+
+func TestDecryptCBC(t *testing.T) {
+	// Encrypt with crypto/aes.
+	padded := pad(input, 16)
+	ciphertext := make([]byte, 16+len(padded))
+	copy(ciphertext, iv)
+
+	c, _ := aes.NewCipher(key)
+	cipher.NewCBCEncrypter(c, iv).CryptBlocks(ciphertext[16:], padded)
+
+	constr, inputMask, outputMask := decryptionKeys(key)
+
+	cand, err := DecryptCBC(constr, inputMask, outputMask, ciphertext)
+	if err != nil {
+		t.Fatalf("DecryptCBC returned error: %v", err)
+	} else if !bytes.Equal(input, cand) {
+		t.Fatalf("Real disagrees with result! %x != %x", input, cand)
+	}
+
+	if _, err := DecryptCBC(constr, inputMask, outputMask, ciphertext[:len(ciphertext)-1]); err == nil {
+		t.Fatal("DecryptCBC accepted a truncated ciphertext!")
+	}
+}
+
+func TestInvertibleCBC(t *testing.T) {
+	constr, inputMask, outputMask := toy.GenerateKeys(key, seed)
+
+	ciphertext, err := EncryptCBC(constr, inputMask, outputMask, input)
+	if err != nil {
+		t.Fatalf("EncryptCBC returned error: %v", err)
+	}
+
+	// Check the ciphertext against crypto/aes before decrypting it with the same construction.
+	c, _ := aes.NewCipher(key)
+	real := make([]byte, len(ciphertext)-16)
+	cipher.NewCBCDecrypter(c, ciphertext[:16]).CryptBlocks(real, ciphertext[16:])
+	real = real[:len(real)-int(real[len(real)-1])]
+
+	if !bytes.Equal(input, real) {
+		t.Fatalf("Real disagrees with result! %x != %x", input, real)
+	}
+
+	cand, err := DecryptCBC(constr, inputMask, outputMask, ciphertext)
+	if err != nil {
+		t.Fatalf("DecryptCBC returned error: %v", err)
+	} else if !bytes.Equal(input, cand) {
+		t.Fatalf("Decryption disagrees with input! %x != %x", input, cand)
+	}
+}
+
+func TestGCM(t *testing.T) {
+	nonce, additionalData := iv[:12], []byte("header")
+
+	// Calculate the candidate output.
+	constr, inputMask, outputMask := encryptionKeys(key)
+	aead := NewGCM(constr, inputMask, outputMask)
+	cand := aead.Seal(nil, nonce, input, additionalData)
+
+	// Calculate the real output.
+	c, _ := aes.NewCipher(key)
+	gcm, _ := cipher.NewGCM(c)
+	real := gcm.Seal(nil, nonce, input, additionalData)
+
+	if !bytes.Equal(real, cand) {
+		t.Fatalf("Real disagrees with result! %x != %x", real, cand)
+	}
+
+	plaintext, err := aead.Open(nil, nonce, cand, additionalData)
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	} else if !bytes.Equal(input, plaintext) {
+		t.Fatalf("Real disagrees with opened! %x != %x", input, plaintext)
+	}
+
+	cand[0] ^= 0x01
+	if _, err := aead.Open(nil, nonce, cand, additionalData); err == nil {
+		t.Fatal("Open accepted a forged ciphertext!")
+	}
+}
+
+func TestCMAC(t *testing.T) {
+	// Test vectors from RFC 4493.
+	key, _ := hex.DecodeString("2b7e151628aed2a6abf7158809cf4f3c")
+	message, _ := hex.DecodeString("6bc1bee22e409f96e93d7e117393172aae2d8a571e03ac9c9eb76fac45af8e5130c81c46a35ce411" +
+		"e5fbc1191a0a52eff69f2445df4f9b17ad2b417be66c3710")
+
+	vectors := []struct {
+		length int
+		tag    string
+	}{
+		{0, "bb1d6929e95937287fa37d129b756746"},
+		{16, "070a16b46b4d4144f79bdd9dd04a287c"},
+		{40, "dfa66747de9ae63030ca32611497c827"},
+		{64, "51f0bebf7e3b9d92fc49741779363cfe"},
+	}
+
+	constr, inputMask, outputMask := encryptionKeys(key)
+
+	for n, vec := range vectors {
+		mac := NewCMAC(constr, inputMask, outputMask)
+		mac.Write(message[:vec.length])
+
+		if cand := hex.EncodeToString(mac.Sum(nil)); vec.tag != cand {
+			t.Fatalf("Real disagrees with result in test vector %v! %v != %v", n, vec.tag, cand)
+		}
+	}
+}
+
+func TestKeyWrap(t *testing.T) {
+	// Test vector from RFC 3394, section 4.1.
+	kek, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	keyData, _ := hex.DecodeString("00112233445566778899aabbccddeeff")
+	real, _ := hex.DecodeString("1fa68b0a8112b447aef34bd8fb5a7b829d3e862371d2cfe5")
+
+	constr, inputMask, outputMask := encryptionKeys(kek)
+
+	cand, err := KeyWrap(constr, inputMask, outputMask, keyData)
+	if err != nil {
+		t.Fatalf("KeyWrap returned error: %v", err)
+	} else if !bytes.Equal(real, cand) {
+		t.Fatalf("Real disagrees with result! %x != %x", real, cand)
+	}
+
+	constr, inputMask, outputMask = decryptionKeys(kek)
+
+	unwrapped, err := KeyUnwrap(constr, inputMask, outputMask, real)
+	if err != nil {
+		t.Fatalf("KeyUnwrap returned error: %v", err)
+	} else if !bytes.Equal(keyData, unwrapped) {
+		t.Fatalf("Real disagrees with unwrapped! %x != %x", keyData, unwrapped)
+	}
+
+	real[0] ^= 0x01
+	if _, err := KeyUnwrap(constr, inputMask, outputMask, real); err == nil {
+		t.Fatal("KeyUnwrap accepted a corrupted wrapped key!")
+	}
+}
+
+func TestXTS(t *testing.T) {
+	// Test vector 2 from IEEE 1619.
+	key1, key2 := bytes.Repeat([]byte{0x11}, 16), bytes.Repeat([]byte{0x22}, 16)
+	plaintext := bytes.Repeat([]byte{0x44}, 32)
+	real, _ := hex.DecodeString("c454185e6a16936e39334038acef838bfb186fff7480adc4289382ecd6d394f0")
+
+	data, dataInputMask, dataOutputMask := encryptionKeys(key1)
+	tweak, tweakInputMask, tweakOutputMask := encryptionKeys(key2)
+
+	cand := make([]byte, len(plaintext))
+	xts := NewXTS(data, dataInputMask, dataOutputMask, tweak, tweakInputMask, tweakOutputMask)
+	xts.Encrypt(cand, plaintext, 0x3333333333)
+
+	if !bytes.Equal(real, cand) {
+		t.Fatalf("Real disagrees with result! %x != %x", real, cand)
+	}
+
+	data, dataInputMask, dataOutputMask = decryptionKeys(key1)
+	xts = NewXTS(data, dataInputMask, dataOutputMask, tweak, tweakInputMask, tweakOutputMask)
+	xts.Decrypt(cand, cand, 0x3333333333)
+
+	if !bytes.Equal(plaintext, cand) {
+		t.Fatalf("Real disagrees with decrypted! %x != %x", plaintext, cand)
+	}
+}
+
+func TestMaskedBlock(t *testing.T) {
+	opts := common.IndependentMasks{common.RandomMask, common.RandomMask}
+	constr, inputMask, outputMask := chow.GenerateEncryptionKeys(key, seed, opts)
+	block := NewLinearMaskedBlock(constr, inputMask, outputMask)
+
+	c, _ := aes.NewCipher(key)
+
+	for i := 0; i+16 <= len(input); i += 16 {
+		cand, real := make([]byte, 16), make([]byte, 16)
+
+		block.Encrypt(cand, input[i:i+16])
+		c.Encrypt(real, input[i:i+16])
+
+		if !bytes.Equal(real, cand) {
+			t.Fatalf("Real disagrees with result! %x != %x", real, cand)
+		}
+	}
+}
+
+func TestBoundMaskedBlock(t *testing.T) {
+	fingerprint := []byte("device 1")
+
+	opts := common.DeviceBinding{Masks: common.MatchingMasks{}, Fingerprint: fingerprint}
+	constr, inputMask, outputMask := chow.GenerateEncryptionKeys(key, seed, opts)
+	inputMask, outputMask = common.UnbindMasks(inputMask, outputMask, fingerprint)
+
+	cand, real := make([]byte, 16), make([]byte, 16)
+
+	NewBoundMaskedBlock(constr, inputMask, outputMask, fingerprint).Encrypt(cand, input)
+
+	c, _ := aes.NewCipher(key)
+	c.Encrypt(real, input)
+
+	if !bytes.Equal(real, cand) {
+		t.Fatalf("Real disagrees with result! %x != %x", real, cand)
+	}
+
+	NewBoundMaskedBlock(constr, inputMask, outputMask, []byte("device 2")).Encrypt(cand, input)
+	if bytes.Equal(real, cand) {
+		t.Fatal("Construction works on a different device!")
+	}
+}
+
+func TestStream(t *testing.T) {
+	constr, inputMask, outputMask := encryptionKeys(key)
+
+	// Encrypt in uneven chunks.
+	buff := &bytes.Buffer{}
+	w := NewEncryptingWriter(buff, constr, inputMask, outputMask, iv)
+	for i := 0; i < len(input); i += 7 {
+		end := i + 7
+		if end > len(input) {
+			end = len(input)
+		}
+
+		if _, err := w.Write(input[i:end]); err != nil {
+			t.Fatalf("Write returned error: %v", err)
+		}
+	}
+
+	real := make([]byte, len(input))
+	c, _ := aes.NewCipher(key)
+	cipher.NewCTR(c, iv).XORKeyStream(real, input)
+
+	if !bytes.Equal(real, buff.Bytes()) {
+		t.Fatalf("Real disagrees with result! %x != %x", real, buff.Bytes())
+	}
+
+	// Decrypt and check we get the input back.
+	cand, err := ioutil.ReadAll(NewDecryptingReader(buff, constr, inputMask, outputMask, iv))
+	if err != nil {
+		t.Fatalf("ReadAll returned error: %v", err)
+	} else if !bytes.Equal(input, cand) {
+		t.Fatalf("Decryption disagrees with input! %x != %x", input, cand)
+	}
+}
+
+func TestFF1(t *testing.T) {
+	// Samples from NIST's FF1 examples.
+	key, _ := hex.DecodeString("2b7e151628aed2a6abf7158809cf4f3c")
+	vectors := []struct {
+		radix                int
+		tweak, input, output string
+	}{
+		{10, "", "0123456789", "2433477484"},
+		{10, "39383736353433323130", "0123456789", "6124200773"},
+		{36, "3737373770717273373737", "0123456789abcdefghi", "a9tv40mll9kdu509eum"},
+	}
+
+	constr, inputMask, outputMask := encryptionKeys(key)
+
+	for _, vec := range vectors {
+		ff, err := NewFF1(constr, inputMask, outputMask, vec.radix)
+		if err != nil {
+			t.Fatalf("NewFF1 returned error: %v", err)
+		}
+		tweak, _ := hex.DecodeString(vec.tweak)
+
+		cand, err := ff.Encrypt(tweak, vec.input)
+		if err != nil {
+			t.Fatalf("Encrypt returned error: %v", err)
+		} else if cand != vec.output {
+			t.Fatalf("Real disagrees with result! %v != %v", vec.output, cand)
+		}
+
+		cand, err = ff.Decrypt(tweak, vec.output)
+		if err != nil {
+			t.Fatalf("Decrypt returned error: %v", err)
+		} else if cand != vec.input {
+			t.Fatalf("Decryption disagrees with input! %v != %v", vec.input, cand)
+		}
+	}
+}
+
+func TestSIV(t *testing.T) {
+	// Deterministic authenticated encryption example from RFC 5297, Appendix A.1.
+	key, _ := hex.DecodeString("fffefdfcfbfaf9f8f7f6f5f4f3f2f1f0f0f1f2f3f4f5f6f7f8f9fafbfcfdfeff")
+	ad, _ := hex.DecodeString("101112131415161718191a1b1c1d1e1f2021222324252627")
+	plaintext, _ := hex.DecodeString("112233445566778899aabbccddee")
+	real, _ := hex.DecodeString("85632d07c6e8f37f950acd320a2ecc9340c02b9690c4dc04daef7f6afe5c")
+
+	mac, macInputMask, macOutputMask := encryptionKeys(key[:16])
+	ctr, ctrInputMask, ctrOutputMask := encryptionKeys(key[16:])
+	siv := NewSIV(mac, macInputMask, macOutputMask, ctr, ctrInputMask, ctrOutputMask)
+
+	cand := siv.Seal(nil, plaintext, ad)
+	if !bytes.Equal(real, cand) {
+		t.Fatalf("Real disagrees with result! %x != %x", real, cand)
+	}
+
+	cand, err := siv.Open(nil, real, ad)
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	} else if !bytes.Equal(plaintext, cand) {
+		t.Fatalf("Decryption disagrees with plaintext! %x != %x", plaintext, cand)
+	}
+
+	real[len(real)-1] ^= 1
+	if _, err := siv.Open(nil, real, ad); err == nil {
+		t.Fatalf("Open accepted a modified ciphertext!")
+	}
+}
@@ -0,0 +1,101 @@
+package modes
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"errors"
+	"hash"
+
+	"github.com/OpenWhiteBox/primitives/encoding"
+)
+
+// SIV computes AES-SIV (RFC 5297) with white-box constructions. It's a deterministic AEAD: encrypting the same
+// plaintext and additional data twice gives the same ciphertext, but repeating a nonce reveals nothing beyond that.
+type SIV struct {
+	mac hash.Hash
+	ctr cipher.Block
+}
+
+// NewSIV returns an SIV cipher. mac is the white-box construction for the first key, which authenticates with S2V. ctr
+// is the white-box construction for the second key, which encrypts in counter mode. Each construction's masks are given
+// after it and are removed internally.
+//
+// SIV only uses the forwards direction of the block cipher, so both should be encryption constructions.
+func NewSIV(mac cipher.Block, macInputMask, macOutputMask encoding.Block, ctr cipher.Block, ctrInputMask, ctrOutputMask encoding.Block) *SIV {
+	return &SIV{
+		mac: NewCMAC(mac, macInputMask, macOutputMask),
+		ctr: NewMaskedBlock(ctr, ctrInputMask, ctrOutputMask),
+	}
+}
+
+// Seal encrypts and authenticates plaintext and each component of additionalData, and appends the synthetic IV and
+// ciphertext to dst. To use a nonce, pass it as the last component of additionalData.
+func (s *SIV) Seal(dst, plaintext []byte, additionalData ...[]byte) []byte {
+	v := s.s2v(plaintext, additionalData)
+
+	out := make([]byte, 16+len(plaintext))
+	copy(out, v)
+	s.xorKeyStream(v, out[16:], plaintext)
+
+	return append(dst, out...)
+}
+
+// Open authenticates and decrypts ciphertext and each component of additionalData, and appends the plaintext to dst.
+// It returns an error if authentication fails.
+func (s *SIV) Open(dst, ciphertext []byte, additionalData ...[]byte) ([]byte, error) {
+	if len(ciphertext) < 16 {
+		return nil, errors.New("message authentication failed")
+	}
+
+	v, ciphertext := ciphertext[:16], ciphertext[16:]
+
+	out := make([]byte, len(ciphertext))
+	s.xorKeyStream(v, out, ciphertext)
+
+	if subtle.ConstantTimeCompare(v, s.s2v(out, additionalData)) != 1 {
+		return nil, errors.New("message authentication failed")
+	}
+
+	return append(dst, out...), nil
+}
+
+// s2v computes the S2V pseudorandom function over each component of additionalData and then plaintext.
+func (s *SIV) s2v(plaintext []byte, additionalData [][]byte) []byte {
+	d := s.sum(make([]byte, 16))
+
+	for _, ad := range additionalData {
+		d = double(d)
+		encoding.XOR(d, d, s.sum(ad))
+	}
+
+	var t []byte
+	if len(plaintext) >= 16 {
+		t = append([]byte{}, plaintext...)
+		encoding.XOR(t[len(t)-16:], t[len(t)-16:], d)
+	} else {
+		t = make([]byte, 16)
+		copy(t, plaintext)
+		t[len(plaintext)] = 0x80
+
+		encoding.XOR(t, t, double(d))
+	}
+
+	return s.sum(t)
+}
+
+// sum returns the CMAC of in.
+func (s *SIV) sum(in []byte) []byte {
+	s.mac.Reset()
+	s.mac.Write(in)
+
+	return s.mac.Sum(nil)
+}
+
+// xorKeyStream encrypts src into dst in counter mode, starting at the synthetic IV v with two of its bits cleared.
+func (s *SIV) xorKeyStream(v, dst, src []byte) {
+	iv := append([]byte{}, v...)
+	iv[8] &= 0x7f
+	iv[12] &= 0x7f
+
+	cipher.NewCTR(s.ctr, iv).XORKeyStream(dst, src)
+}
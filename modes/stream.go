@@ -0,0 +1,27 @@
+package modes
+
+import (
+	"crypto/cipher"
+	"io"
+
+	"github.com/OpenWhiteBox/primitives/encoding"
+)
+
+// NewEncryptingWriter returns an io.Writer which encrypts everything written to it in CTR mode with the white-box
+// construction constr, starting at the counter block iv, and writes the ciphertext to w. inputMask and outputMask are
+// the masks constr was generated with. Data can be written in chunks of any size.
+//
+// If w is an io.Closer, closing the returned writer closes w.
+func NewEncryptingWriter(w io.Writer, constr cipher.Block, inputMask, outputMask encoding.Block, iv []byte) io.WriteCloser {
+	return cipher.StreamWriter{S: NewCTR(constr, inputMask, outputMask, iv), W: w}
+}
+
+// NewDecryptingReader returns an io.Reader which reads CTR-mode ciphertext from r and decrypts it with the white-box
+// construction constr, starting at the counter block iv. inputMask and outputMask are the masks constr was generated
+// with.
+//
+// Like NewCTR, this only uses the forwards direction of the block cipher, so constr should be an encryption
+// construction--the same one used with NewEncryptingWriter.
+func NewDecryptingReader(r io.Reader, constr cipher.Block, inputMask, outputMask encoding.Block, iv []byte) io.Reader {
+	return cipher.StreamReader{S: NewCTR(constr, inputMask, outputMask, iv), R: r}
+}
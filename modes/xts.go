@@ -0,0 +1,77 @@
+package modes
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+
+	"github.com/OpenWhiteBox/primitives/encoding"
+)
+
+// XTS computes XTS-AES (IEEE 1619) with white-box constructions, for encrypting sectors of a disk.
+type XTS struct {
+	data, tweak cipher.Block
+}
+
+// NewXTS returns an XTS cipher. data is the white-box construction for the first key, which encrypts sectors. tweak is
+// the white-box construction for the second key, which encrypts sector numbers into tweaks. Each construction's masks
+// are given after it and are removed internally.
+//
+// Most white-box constructions only compute one direction of AES, so which kind of construction data has to be depends
+// on what it's used for: an encryption construction for Encrypt and a decryption construction for Decrypt, unless it's
+// Invertible. tweak is always an encryption construction. Passing the same encryption construction twice derives tweaks
+// from the data key.
+func NewXTS(data cipher.Block, dataInputMask, dataOutputMask encoding.Block, tweak cipher.Block, tweakInputMask, tweakOutputMask encoding.Block) *XTS {
+	return &XTS{
+		data:  NewMaskedBlock(data, dataInputMask, dataOutputMask),
+		tweak: NewMaskedBlock(tweak, tweakInputMask, tweakOutputMask),
+	}
+}
+
+// Encrypt encrypts the sector plaintext into ciphertext. The length of plaintext must be a multiple of 16 bytes, and
+// ciphertext must be at least as long. Dst and src may point at the same memory.
+func (x *XTS) Encrypt(ciphertext, plaintext []byte, sectorNum uint64) {
+	x.crypt(ciphertext, plaintext, sectorNum, x.data.Encrypt)
+}
+
+// Decrypt decrypts the sector ciphertext into plaintext. The length of ciphertext must be a multiple of 16 bytes, and
+// plaintext must be at least as long. Dst and src may point at the same memory.
+func (x *XTS) Decrypt(plaintext, ciphertext []byte, sectorNum uint64) {
+	x.crypt(plaintext, ciphertext, sectorNum, x.data.Decrypt)
+}
+
+// crypt pushes each block of src through f, masked with the tweak for that block, and writes the result to dst.
+func (x *XTS) crypt(dst, src []byte, sectorNum uint64, f func(dst, src []byte)) {
+	if len(src)%16 != 0 {
+		panic("modes: XTS input is not a multiple of the block size")
+	} else if len(dst) < len(src) {
+		panic("modes: XTS output is smaller than input")
+	}
+
+	tweak, block := make([]byte, 16), make([]byte, 16)
+	binary.LittleEndian.PutUint64(tweak, sectorNum)
+	x.tweak.Encrypt(tweak, tweak)
+
+	for len(src) > 0 {
+		encoding.XOR(block, src[:16], tweak)
+		f(block, block)
+		encoding.XOR(dst[:16], block, tweak)
+
+		mulAlpha(tweak)
+		dst, src = dst[16:], src[16:]
+	}
+}
+
+// mulAlpha multiplies the tweak by the primitive element of GF(2^128), in XTS' little-endian bit order.
+func mulAlpha(tweak []byte) {
+	carry := byte(0)
+
+	for i := 0; i < 16; i++ {
+		next := tweak[i] >> 7
+		tweak[i] = tweak[i]<<1 | carry
+		carry = next
+	}
+
+	if carry != 0 {
+		tweak[0] ^= 0x87
+	}
+}
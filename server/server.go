@@ -0,0 +1,191 @@
+// Package server exposes white-box key generation over an authenticated HTTP API.
+//
+// A client POSTs a Request to the server naming an AES key by its handle, and gets back a serialized construction for
+// that key, the construction's metadata envelope, and its external masks. AES keys never leave the server; they're
+// looked up by handle in a KeyStore.
+//
+// Each request's generation seed is derived from the server's master seed with a labeled random source, keyed by the
+// key handle and a fresh random nonce. The nonce is returned with the response, so the master seed, handle, and nonce
+// are enough to regenerate the construction later.
+package server
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/OpenWhiteBox/primitives/random"
+
+	"github.com/OpenWhiteBox/AES/constructions/common"
+	"github.com/OpenWhiteBox/AES/internal/generate"
+)
+
+// maxRequestSize is the largest request body the server will read.
+const maxRequestSize = 1 << 16
+
+// KeyStore looks up AES keys by handle.
+type KeyStore interface {
+	Key(handle string) ([]byte, error)
+}
+
+// MapKeyStore is a KeyStore backed by a map from handles to keys.
+type MapKeyStore map[string][]byte
+
+// Key returns the key with the given handle, or an error if there isn't one.
+func (mks MapKeyStore) Key(handle string) ([]byte, error) {
+	key, ok := mks[handle]
+	if !ok {
+		return nil, fmt.Errorf("unknown key handle %q", handle)
+	}
+
+	return key, nil
+}
+
+// Authenticator decides whether an HTTP request may generate keys.
+type Authenticator func(r *http.Request) bool
+
+// BearerToken returns an Authenticator which accepts requests with the header "Authorization: Bearer <token>".
+func BearerToken(token string) Authenticator {
+	expected := []byte("Bearer " + token)
+
+	return func(r *http.Request) bool {
+		return subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) == 1
+	}
+}
+
+// Request is a request for a new construction.
+type Request struct {
+	Handle       string `json:"handle"`          // The handle of the AES key in the server's KeyStore.
+	Construction string `json:"construction"`    // chow, xiao, toy, or full.
+	Masks        string `json:"masks,omitempty"` // For chow and xiao: random (the default), identity, or matching.
+}
+
+// Response is a newly generated construction. Package is base64-encoded in JSON, like all []byte fields.
+type Response struct {
+	Nonce    string          `json:"nonce"` // Hex-encoded. Labels the derivation of this construction's seed.
+	Package  []byte          `json:"package"`
+	Metadata common.Envelope `json:"metadata"`
+
+	// The external masks of the construction. These are secret.
+	InputMask  Mask `json:"inputMask"`
+	OutputMask Mask `json:"outputMask"`
+}
+
+// Mask is a hex-encoded affine mask. Linear holds the rows of the matrix.
+type Mask = generate.Mask
+
+// Server generates constructions for keys in a KeyStore. It implements http.Handler.
+type Server struct {
+	keys   KeyStore
+	source random.Source
+	auth   Authenticator
+}
+
+// New returns a new server which generates constructions for keys in keys, derives each construction's seed from seed,
+// and accepts the HTTP requests that auth accepts.
+func New(keys KeyStore, seed []byte, auth Authenticator) *Server {
+	masterSeed := sha256.Sum256(seed)
+
+	return &Server{
+		keys:   keys,
+		source: random.NewSource("Keygen Server", masterSeed[:16]),
+		auth:   auth,
+	}
+}
+
+// ServeHTTP handles a JSON-encoded Request POSTed to any path, and writes the JSON-encoded Response.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	} else if !s.auth(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	req := Request{}
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxRequestSize)).Decode(&req); err != nil {
+		http.Error(w, "malformed request", http.StatusBadRequest)
+		return
+	}
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	res, err := s.Generate(req, nonce)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
+
+// Generate handles a request directly, without HTTP or authentication. nonce labels the derivation of the
+// construction's seed; requests with the same handle and nonce get the same construction.
+func (s *Server) Generate(req Request, nonce []byte) (*Response, error) {
+	key, err := s.keys.Key(req.Handle)
+	if err != nil {
+		return nil, err
+	} else if len(key) != 16 {
+		return nil, errors.New("key must be 128 bits")
+	}
+
+	// Masks default to random.
+	masks := req.Masks
+	if masks == "" {
+		masks = "random"
+	}
+
+	opts, ok := generate.MaskOpts[masks]
+	if !ok {
+		return nil, fmt.Errorf("unknown masks %q", req.Masks)
+	}
+
+	constrType, err := generate.ParseType(req.Construction)
+	if err != nil {
+		return nil, err
+	}
+
+	seed := s.seed(req.Handle, nonce)
+
+	generated, err := generate.Construction(constrType, opts, key, seed)
+	if err != nil {
+		return nil, err
+	}
+	serialized := generated.Construction.Serialize()
+
+	return &Response{
+		Nonce:    hex.EncodeToString(nonce),
+		Package:  serialized,
+		Metadata: common.NewEnvelope(constrType, generated.Opts, seed, serialized),
+
+		InputMask:  generate.NewMask(generated.InputMask),
+		OutputMask: generate.NewMask(generated.OutputMask),
+	}, nil
+}
+
+// seed derives the generation seed of a request from the server's master seed. The random source takes 16-byte labels,
+// so the label is a hash of the handle and nonce. The handle is length-prefixed so that distinct (handle, nonce) pairs
+// never hash the same input. Requests are capped at maxRequestSize, so the length fits in two bytes.
+func (s *Server) seed(handle string, nonce []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{byte(len(handle) >> 8), byte(len(handle))})
+	h.Write([]byte(handle))
+	h.Write(nonce)
+
+	seed := make([]byte, 16)
+	io.ReadFull(s.source.Stream(h.Sum(nil)[:16]), seed)
+
+	return seed
+}
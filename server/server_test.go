@@ -0,0 +1,73 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/OpenWhiteBox/AES/constructions/toy"
+)
+
+var (
+	key  = []byte{72, 101, 108, 108, 111, 32, 87, 111, 114, 108, 100, 33, 33, 33, 33, 33}
+	seed = []byte{38, 41, 142, 156, 29, 181, 23, 194, 21, 250, 223, 183, 210, 168, 214, 145}
+)
+
+func TestServer(t *testing.T) {
+	ts := httptest.NewServer(New(MapKeyStore{"test": key}, seed, BearerToken("secret")))
+	defer ts.Close()
+
+	post := func(token, body string) *http.Response {
+		req, _ := http.NewRequest("POST", ts.URL, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Request returned error: %v", err)
+		}
+		return res
+	}
+
+	if res := post("wrong", `{"handle": "test", "construction": "toy"}`); res.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("Server accepted the wrong token! Status %v", res.StatusCode)
+	} else if res := post("secret", `{"handle": "other", "construction": "toy"}`); res.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Server accepted an unknown key handle! Status %v", res.StatusCode)
+	}
+
+	res := post("secret", `{"handle": "test", "construction": "toy"}`)
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("Server returned status %v", res.StatusCode)
+	}
+
+	parsed := Response{}
+	if err := json.NewDecoder(res.Body).Decode(&parsed); err != nil {
+		t.Fatalf("Server returned malformed response: %v", err)
+	} else if err := parsed.Metadata.Check(parsed.Package); err != nil {
+		t.Fatalf("Metadata doesn't match package: %v", err)
+	} else if _, err := toy.Parse(parsed.Package); err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	s := New(MapKeyStore{"test": key}, seed, BearerToken("secret"))
+	req := Request{Handle: "test", Construction: "toy"}
+
+	res1, err := s.Generate(req, []byte{1})
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+	res2, _ := s.Generate(req, []byte{1})
+	res3, _ := s.Generate(req, []byte{2})
+
+	if !bytes.Equal(res1.Package, res2.Package) {
+		t.Fatal("Same nonce gave different constructions!")
+	} else if bytes.Equal(res1.Package, res3.Package) {
+		t.Fatal("Different nonces gave the same construction!")
+	}
+}
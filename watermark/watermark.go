@@ -0,0 +1,114 @@
+// Package watermark identifies which customer a leaked white-box construction was generated for.
+//
+// Constructions are watermarked at key generation with the common.Watermark option, which draws every internal encoding
+// from randomness that depends on a per-customer mark. Constructions with different marks compute the same function
+// under the same external masks, but their tables differ. Given a leaked construction and the parameters it was
+// generated with, Detect regenerates it under each candidate mark and compares tables. Only table contents are
+// compared, so the leaked construction may have been parsed and re-serialized any number of times.
+//
+// Watermarks are supported by the constructions which take key generation options: chow and xiao.
+package watermark
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"errors"
+	"fmt"
+
+	"github.com/OpenWhiteBox/primitives/matrix"
+
+	"github.com/OpenWhiteBox/AES/constructions/chow"
+	"github.com/OpenWhiteBox/AES/constructions/common"
+	"github.com/OpenWhiteBox/AES/constructions/xiao"
+)
+
+// Params are the arguments a watermarked construction was generated with, other than the mark.
+type Params struct {
+	Key, Seed []byte
+	Masks     common.KeyGenerationOpts // The masks wrapped by common.Watermark.
+	Decrypt   bool                     // Whether the construction was generated for decryption.
+}
+
+// Detect returns the index of the mark in marks that constr was generated with. constr must be a chow or xiao
+// construction. It returns an error if none of the marks match.
+func Detect(constr cipher.Block, params Params, marks [][]byte) (int, error) {
+	var match func(opts common.KeyGenerationOpts) bool
+
+	switch leaked := constr.(type) {
+	case chow.Construction:
+		return Detect(&leaked, params, marks)
+	case xiao.Construction:
+		return Detect(&leaked, params, marks)
+	case *chow.Construction:
+		generate := chow.GenerateEncryptionKeys
+		if params.Decrypt {
+			generate = chow.GenerateDecryptionKeys
+		}
+
+		match = func(opts common.KeyGenerationOpts) bool {
+			cand, _, _ := generate(params.Key, params.Seed, opts)
+			return matchChow(leaked, &cand)
+		}
+	case *xiao.Construction:
+		generate := xiao.GenerateEncryptionKeys
+		if params.Decrypt {
+			generate = xiao.GenerateDecryptionKeys
+		}
+
+		match = func(opts common.KeyGenerationOpts) bool {
+			cand, _, _ := generate(params.Key, params.Seed, opts)
+			return matchXiao(leaked, &cand)
+		}
+	default:
+		return -1, fmt.Errorf("can't detect watermarks in %T", constr)
+	}
+
+	for i, mark := range marks {
+		if match(common.Watermark{Masks: params.Masks, Mark: mark}) {
+			return i, nil
+		}
+	}
+
+	return -1, errors.New("no mark matches the construction")
+}
+
+// matchChow compares a sample of round tables. Every internal encoding of a round is drawn after the masks, so any
+// round tells marks apart; checking several guards against chance agreement of a single table.
+func matchChow(a, b *chow.Construction) bool {
+	for _, round := range []int{0, 4, 8} {
+		for x := 0; x < 256; x++ {
+			if a.TBoxTyiTable[round][0].Get(byte(x)) != b.TBoxTyiTable[round][0].Get(byte(x)) {
+				return false
+			} else if a.HighXORTable[round][0][0].Get(byte(x)) != b.HighXORTable[round][0][0].Get(byte(x)) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// matchXiao compares the re-encoding matrices between rounds, which are mostly internal encodings.
+func matchXiao(a, b *xiao.Construction) bool {
+	for round := 1; round < 10; round++ {
+		if !equalMatrices(a.ShiftRows[round], b.ShiftRows[round]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func equalMatrices(a, b matrix.Matrix) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if !bytes.Equal(a[i], b[i]) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,57 @@
+package watermark
+
+import (
+	"testing"
+
+	"github.com/OpenWhiteBox/AES/constructions/chow"
+	"github.com/OpenWhiteBox/AES/constructions/common"
+	"github.com/OpenWhiteBox/AES/constructions/xiao"
+)
+
+var (
+	key  = []byte{72, 101, 108, 108, 111, 32, 87, 111, 114, 108, 100, 33, 33, 33, 33, 33}
+	seed = []byte{38, 41, 142, 156, 29, 181, 23, 194, 21, 250, 223, 183, 210, 168, 214, 145}
+
+	marks = [][]byte{[]byte("customer a"), []byte("customer b"), []byte("customer c")}
+)
+
+func TestDetectChow(t *testing.T) {
+	masks := common.IndependentMasks{Input: common.RandomMask, Output: common.RandomMask}
+	params := Params{Key: key, Seed: seed, Masks: masks}
+
+	constr, _, _ := chow.GenerateEncryptionKeys(key, seed, common.Watermark{Masks: params.Masks, Mark: marks[1]})
+
+	// The leaked construction has been serialized and parsed again.
+	leaked, err := chow.Parse(constr.Serialize())
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+
+	if i, err := Detect(leaked, params, marks); err != nil {
+		t.Fatalf("Detect returned error: %v", err)
+	} else if i != 1 {
+		t.Fatalf("Detect identified mark %v, expected 1", i)
+	}
+
+	if _, err := Detect(leaked, params, [][]byte{marks[0], marks[2]}); err == nil {
+		t.Fatal("Detect matched the wrong mark!")
+	}
+}
+
+func TestDetectXiao(t *testing.T) {
+	params := Params{Key: key, Seed: seed, Masks: common.MatchingMasks{}}
+
+	constr, inputMask, _ := xiao.GenerateEncryptionKeys(key, seed, common.Watermark{Masks: params.Masks, Mark: marks[2]})
+
+	if i, err := Detect(constr, params, marks); err != nil {
+		t.Fatalf("Detect returned error: %v", err)
+	} else if i != 2 {
+		t.Fatalf("Detect identified mark %v, expected 2", i)
+	}
+
+	// The watermark shouldn't change the external masks.
+	_, unmarked, _ := xiao.GenerateEncryptionKeys(key, seed, params.Masks)
+	if !equalMatrices(inputMask, unmarked) {
+		t.Fatal("Watermark changed the input mask!")
+	}
+}